@@ -0,0 +1,1564 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: api/hlcup.proto
+
+package api
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+import wrappers "github.com/golang/protobuf/ptypes/wrappers"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+// Fields of entities are wrapped to distinguish missing fields from zero values.
+type User struct {
+	Id                   *wrappers.UInt32Value `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Email                *wrappers.StringValue `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	FirstName            *wrappers.StringValue `protobuf:"bytes,3,opt,name=first_name,json=firstName,proto3" json:"first_name,omitempty"`
+	LastName             *wrappers.StringValue `protobuf:"bytes,4,opt,name=last_name,json=lastName,proto3" json:"last_name,omitempty"`
+	Gender               *wrappers.StringValue `protobuf:"bytes,5,opt,name=gender,proto3" json:"gender,omitempty"`
+	BirthDate            *wrappers.Int32Value  `protobuf:"bytes,6,opt,name=birth_date,json=birthDate,proto3" json:"birth_date,omitempty"`
+	Version              *wrappers.UInt64Value `protobuf:"bytes,7,opt,name=version,proto3" json:"version,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *User) Reset()         { *m = User{} }
+func (m *User) String() string { return proto.CompactTextString(m) }
+func (*User) ProtoMessage()    {}
+func (*User) Descriptor() ([]byte, []int) {
+	return fileDescriptor_hlcup_fa6ade7d4881b9e7, []int{0}
+}
+func (m *User) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_User.Unmarshal(m, b)
+}
+func (m *User) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_User.Marshal(b, m, deterministic)
+}
+func (dst *User) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_User.Merge(dst, src)
+}
+func (m *User) XXX_Size() int {
+	return xxx_messageInfo_User.Size(m)
+}
+func (m *User) XXX_DiscardUnknown() {
+	xxx_messageInfo_User.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_User proto.InternalMessageInfo
+
+func (m *User) GetId() *wrappers.UInt32Value {
+	if m != nil {
+		return m.Id
+	}
+	return nil
+}
+
+func (m *User) GetEmail() *wrappers.StringValue {
+	if m != nil {
+		return m.Email
+	}
+	return nil
+}
+
+func (m *User) GetFirstName() *wrappers.StringValue {
+	if m != nil {
+		return m.FirstName
+	}
+	return nil
+}
+
+func (m *User) GetLastName() *wrappers.StringValue {
+	if m != nil {
+		return m.LastName
+	}
+	return nil
+}
+
+func (m *User) GetGender() *wrappers.StringValue {
+	if m != nil {
+		return m.Gender
+	}
+	return nil
+}
+
+func (m *User) GetBirthDate() *wrappers.Int32Value {
+	if m != nil {
+		return m.BirthDate
+	}
+	return nil
+}
+
+func (m *User) GetVersion() *wrappers.UInt64Value {
+	if m != nil {
+		return m.Version
+	}
+	return nil
+}
+
+type Location struct {
+	Id                   *wrappers.UInt32Value `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Place                *wrappers.StringValue `protobuf:"bytes,2,opt,name=place,proto3" json:"place,omitempty"`
+	Country              *wrappers.StringValue `protobuf:"bytes,3,opt,name=country,proto3" json:"country,omitempty"`
+	City                 *wrappers.StringValue `protobuf:"bytes,4,opt,name=city,proto3" json:"city,omitempty"`
+	Distance             *wrappers.UInt32Value `protobuf:"bytes,5,opt,name=distance,proto3" json:"distance,omitempty"`
+	Version              *wrappers.UInt64Value `protobuf:"bytes,6,opt,name=version,proto3" json:"version,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *Location) Reset()         { *m = Location{} }
+func (m *Location) String() string { return proto.CompactTextString(m) }
+func (*Location) ProtoMessage()    {}
+func (*Location) Descriptor() ([]byte, []int) {
+	return fileDescriptor_hlcup_fa6ade7d4881b9e7, []int{1}
+}
+func (m *Location) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Location.Unmarshal(m, b)
+}
+func (m *Location) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Location.Marshal(b, m, deterministic)
+}
+func (dst *Location) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Location.Merge(dst, src)
+}
+func (m *Location) XXX_Size() int {
+	return xxx_messageInfo_Location.Size(m)
+}
+func (m *Location) XXX_DiscardUnknown() {
+	xxx_messageInfo_Location.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Location proto.InternalMessageInfo
+
+func (m *Location) GetId() *wrappers.UInt32Value {
+	if m != nil {
+		return m.Id
+	}
+	return nil
+}
+
+func (m *Location) GetPlace() *wrappers.StringValue {
+	if m != nil {
+		return m.Place
+	}
+	return nil
+}
+
+func (m *Location) GetCountry() *wrappers.StringValue {
+	if m != nil {
+		return m.Country
+	}
+	return nil
+}
+
+func (m *Location) GetCity() *wrappers.StringValue {
+	if m != nil {
+		return m.City
+	}
+	return nil
+}
+
+func (m *Location) GetDistance() *wrappers.UInt32Value {
+	if m != nil {
+		return m.Distance
+	}
+	return nil
+}
+
+func (m *Location) GetVersion() *wrappers.UInt64Value {
+	if m != nil {
+		return m.Version
+	}
+	return nil
+}
+
+type Visit struct {
+	Id                   *wrappers.UInt32Value `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Location             *wrappers.UInt32Value `protobuf:"bytes,2,opt,name=location,proto3" json:"location,omitempty"`
+	User                 *wrappers.UInt32Value `protobuf:"bytes,3,opt,name=user,proto3" json:"user,omitempty"`
+	VisitedAt            *wrappers.Int32Value  `protobuf:"bytes,4,opt,name=visited_at,json=visitedAt,proto3" json:"visited_at,omitempty"`
+	Mark                 *wrappers.UInt32Value `protobuf:"bytes,5,opt,name=mark,proto3" json:"mark,omitempty"`
+	Version              *wrappers.UInt64Value `protobuf:"bytes,6,opt,name=version,proto3" json:"version,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *Visit) Reset()         { *m = Visit{} }
+func (m *Visit) String() string { return proto.CompactTextString(m) }
+func (*Visit) ProtoMessage()    {}
+func (*Visit) Descriptor() ([]byte, []int) {
+	return fileDescriptor_hlcup_fa6ade7d4881b9e7, []int{2}
+}
+func (m *Visit) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Visit.Unmarshal(m, b)
+}
+func (m *Visit) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Visit.Marshal(b, m, deterministic)
+}
+func (dst *Visit) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Visit.Merge(dst, src)
+}
+func (m *Visit) XXX_Size() int {
+	return xxx_messageInfo_Visit.Size(m)
+}
+func (m *Visit) XXX_DiscardUnknown() {
+	xxx_messageInfo_Visit.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Visit proto.InternalMessageInfo
+
+func (m *Visit) GetId() *wrappers.UInt32Value {
+	if m != nil {
+		return m.Id
+	}
+	return nil
+}
+
+func (m *Visit) GetLocation() *wrappers.UInt32Value {
+	if m != nil {
+		return m.Location
+	}
+	return nil
+}
+
+func (m *Visit) GetUser() *wrappers.UInt32Value {
+	if m != nil {
+		return m.User
+	}
+	return nil
+}
+
+func (m *Visit) GetVisitedAt() *wrappers.Int32Value {
+	if m != nil {
+		return m.VisitedAt
+	}
+	return nil
+}
+
+func (m *Visit) GetMark() *wrappers.UInt32Value {
+	if m != nil {
+		return m.Mark
+	}
+	return nil
+}
+
+func (m *Visit) GetVersion() *wrappers.UInt64Value {
+	if m != nil {
+		return m.Version
+	}
+	return nil
+}
+
+type GetRequest struct {
+	Id                   uint32               `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	AsOf                 *wrappers.Int64Value `protobuf:"bytes,2,opt,name=as_of,json=asOf,proto3" json:"as_of,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *GetRequest) Reset()         { *m = GetRequest{} }
+func (m *GetRequest) String() string { return proto.CompactTextString(m) }
+func (*GetRequest) ProtoMessage()    {}
+func (*GetRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_hlcup_fa6ade7d4881b9e7, []int{3}
+}
+func (m *GetRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetRequest.Unmarshal(m, b)
+}
+func (m *GetRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetRequest.Marshal(b, m, deterministic)
+}
+func (dst *GetRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetRequest.Merge(dst, src)
+}
+func (m *GetRequest) XXX_Size() int {
+	return xxx_messageInfo_GetRequest.Size(m)
+}
+func (m *GetRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetRequest proto.InternalMessageInfo
+
+func (m *GetRequest) GetId() uint32 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *GetRequest) GetAsOf() *wrappers.Int64Value {
+	if m != nil {
+		return m.AsOf
+	}
+	return nil
+}
+
+// Update requests apply set fields of the entity, the entity version, when
+// set, must match the current one.
+type UpdateUserRequest struct {
+	Id                   uint32   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	User                 *User    `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UpdateUserRequest) Reset()         { *m = UpdateUserRequest{} }
+func (m *UpdateUserRequest) String() string { return proto.CompactTextString(m) }
+func (*UpdateUserRequest) ProtoMessage()    {}
+func (*UpdateUserRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_hlcup_fa6ade7d4881b9e7, []int{4}
+}
+func (m *UpdateUserRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpdateUserRequest.Unmarshal(m, b)
+}
+func (m *UpdateUserRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpdateUserRequest.Marshal(b, m, deterministic)
+}
+func (dst *UpdateUserRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdateUserRequest.Merge(dst, src)
+}
+func (m *UpdateUserRequest) XXX_Size() int {
+	return xxx_messageInfo_UpdateUserRequest.Size(m)
+}
+func (m *UpdateUserRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdateUserRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdateUserRequest proto.InternalMessageInfo
+
+func (m *UpdateUserRequest) GetId() uint32 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *UpdateUserRequest) GetUser() *User {
+	if m != nil {
+		return m.User
+	}
+	return nil
+}
+
+type UpdateLocationRequest struct {
+	Id                   uint32    `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Location             *Location `protobuf:"bytes,2,opt,name=location,proto3" json:"location,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *UpdateLocationRequest) Reset()         { *m = UpdateLocationRequest{} }
+func (m *UpdateLocationRequest) String() string { return proto.CompactTextString(m) }
+func (*UpdateLocationRequest) ProtoMessage()    {}
+func (*UpdateLocationRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_hlcup_fa6ade7d4881b9e7, []int{5}
+}
+func (m *UpdateLocationRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpdateLocationRequest.Unmarshal(m, b)
+}
+func (m *UpdateLocationRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpdateLocationRequest.Marshal(b, m, deterministic)
+}
+func (dst *UpdateLocationRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdateLocationRequest.Merge(dst, src)
+}
+func (m *UpdateLocationRequest) XXX_Size() int {
+	return xxx_messageInfo_UpdateLocationRequest.Size(m)
+}
+func (m *UpdateLocationRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdateLocationRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdateLocationRequest proto.InternalMessageInfo
+
+func (m *UpdateLocationRequest) GetId() uint32 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *UpdateLocationRequest) GetLocation() *Location {
+	if m != nil {
+		return m.Location
+	}
+	return nil
+}
+
+type UpdateVisitRequest struct {
+	Id                   uint32   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Visit                *Visit   `protobuf:"bytes,2,opt,name=visit,proto3" json:"visit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UpdateVisitRequest) Reset()         { *m = UpdateVisitRequest{} }
+func (m *UpdateVisitRequest) String() string { return proto.CompactTextString(m) }
+func (*UpdateVisitRequest) ProtoMessage()    {}
+func (*UpdateVisitRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_hlcup_fa6ade7d4881b9e7, []int{6}
+}
+func (m *UpdateVisitRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpdateVisitRequest.Unmarshal(m, b)
+}
+func (m *UpdateVisitRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpdateVisitRequest.Marshal(b, m, deterministic)
+}
+func (dst *UpdateVisitRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdateVisitRequest.Merge(dst, src)
+}
+func (m *UpdateVisitRequest) XXX_Size() int {
+	return xxx_messageInfo_UpdateVisitRequest.Size(m)
+}
+func (m *UpdateVisitRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdateVisitRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdateVisitRequest proto.InternalMessageInfo
+
+func (m *UpdateVisitRequest) GetId() uint32 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *UpdateVisitRequest) GetVisit() *Visit {
+	if m != nil {
+		return m.Visit
+	}
+	return nil
+}
+
+type Created struct {
+	Id                   uint32   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Created) Reset()         { *m = Created{} }
+func (m *Created) String() string { return proto.CompactTextString(m) }
+func (*Created) ProtoMessage()    {}
+func (*Created) Descriptor() ([]byte, []int) {
+	return fileDescriptor_hlcup_fa6ade7d4881b9e7, []int{7}
+}
+func (m *Created) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Created.Unmarshal(m, b)
+}
+func (m *Created) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Created.Marshal(b, m, deterministic)
+}
+func (dst *Created) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Created.Merge(dst, src)
+}
+func (m *Created) XXX_Size() int {
+	return xxx_messageInfo_Created.Size(m)
+}
+func (m *Created) XXX_DiscardUnknown() {
+	xxx_messageInfo_Created.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Created proto.InternalMessageInfo
+
+func (m *Created) GetId() uint32 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+type Updated struct {
+	Version              uint64   `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Updated) Reset()         { *m = Updated{} }
+func (m *Updated) String() string { return proto.CompactTextString(m) }
+func (*Updated) ProtoMessage()    {}
+func (*Updated) Descriptor() ([]byte, []int) {
+	return fileDescriptor_hlcup_fa6ade7d4881b9e7, []int{8}
+}
+func (m *Updated) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Updated.Unmarshal(m, b)
+}
+func (m *Updated) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Updated.Marshal(b, m, deterministic)
+}
+func (dst *Updated) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Updated.Merge(dst, src)
+}
+func (m *Updated) XXX_Size() int {
+	return xxx_messageInfo_Updated.Size(m)
+}
+func (m *Updated) XXX_DiscardUnknown() {
+	xxx_messageInfo_Updated.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Updated proto.InternalMessageInfo
+
+func (m *Updated) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+type PlaceFilter struct {
+	FromDate             *wrappers.Int32Value  `protobuf:"bytes,1,opt,name=from_date,json=fromDate,proto3" json:"from_date,omitempty"`
+	ToDate               *wrappers.Int32Value  `protobuf:"bytes,2,opt,name=to_date,json=toDate,proto3" json:"to_date,omitempty"`
+	Country              *wrappers.StringValue `protobuf:"bytes,3,opt,name=country,proto3" json:"country,omitempty"`
+	Distance             *wrappers.UInt32Value `protobuf:"bytes,4,opt,name=distance,proto3" json:"distance,omitempty"`
+	AsOf                 *wrappers.Int64Value  `protobuf:"bytes,5,opt,name=as_of,json=asOf,proto3" json:"as_of,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *PlaceFilter) Reset()         { *m = PlaceFilter{} }
+func (m *PlaceFilter) String() string { return proto.CompactTextString(m) }
+func (*PlaceFilter) ProtoMessage()    {}
+func (*PlaceFilter) Descriptor() ([]byte, []int) {
+	return fileDescriptor_hlcup_fa6ade7d4881b9e7, []int{9}
+}
+func (m *PlaceFilter) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PlaceFilter.Unmarshal(m, b)
+}
+func (m *PlaceFilter) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PlaceFilter.Marshal(b, m, deterministic)
+}
+func (dst *PlaceFilter) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PlaceFilter.Merge(dst, src)
+}
+func (m *PlaceFilter) XXX_Size() int {
+	return xxx_messageInfo_PlaceFilter.Size(m)
+}
+func (m *PlaceFilter) XXX_DiscardUnknown() {
+	xxx_messageInfo_PlaceFilter.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PlaceFilter proto.InternalMessageInfo
+
+func (m *PlaceFilter) GetFromDate() *wrappers.Int32Value {
+	if m != nil {
+		return m.FromDate
+	}
+	return nil
+}
+
+func (m *PlaceFilter) GetToDate() *wrappers.Int32Value {
+	if m != nil {
+		return m.ToDate
+	}
+	return nil
+}
+
+func (m *PlaceFilter) GetCountry() *wrappers.StringValue {
+	if m != nil {
+		return m.Country
+	}
+	return nil
+}
+
+func (m *PlaceFilter) GetDistance() *wrappers.UInt32Value {
+	if m != nil {
+		return m.Distance
+	}
+	return nil
+}
+
+func (m *PlaceFilter) GetAsOf() *wrappers.Int64Value {
+	if m != nil {
+		return m.AsOf
+	}
+	return nil
+}
+
+type ListUserVisitsRequest struct {
+	Id                   uint32       `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Filter               *PlaceFilter `protobuf:"bytes,2,opt,name=filter,proto3" json:"filter,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *ListUserVisitsRequest) Reset()         { *m = ListUserVisitsRequest{} }
+func (m *ListUserVisitsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListUserVisitsRequest) ProtoMessage()    {}
+func (*ListUserVisitsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_hlcup_fa6ade7d4881b9e7, []int{10}
+}
+func (m *ListUserVisitsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListUserVisitsRequest.Unmarshal(m, b)
+}
+func (m *ListUserVisitsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListUserVisitsRequest.Marshal(b, m, deterministic)
+}
+func (dst *ListUserVisitsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListUserVisitsRequest.Merge(dst, src)
+}
+func (m *ListUserVisitsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListUserVisitsRequest.Size(m)
+}
+func (m *ListUserVisitsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListUserVisitsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListUserVisitsRequest proto.InternalMessageInfo
+
+func (m *ListUserVisitsRequest) GetId() uint32 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *ListUserVisitsRequest) GetFilter() *PlaceFilter {
+	if m != nil {
+		return m.Filter
+	}
+	return nil
+}
+
+type Place struct {
+	Mark                 uint32   `protobuf:"varint,1,opt,name=mark,proto3" json:"mark,omitempty"`
+	VisitedAt            int32    `protobuf:"varint,2,opt,name=visited_at,json=visitedAt,proto3" json:"visited_at,omitempty"`
+	Place                string   `protobuf:"bytes,3,opt,name=place,proto3" json:"place,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Place) Reset()         { *m = Place{} }
+func (m *Place) String() string { return proto.CompactTextString(m) }
+func (*Place) ProtoMessage()    {}
+func (*Place) Descriptor() ([]byte, []int) {
+	return fileDescriptor_hlcup_fa6ade7d4881b9e7, []int{11}
+}
+func (m *Place) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Place.Unmarshal(m, b)
+}
+func (m *Place) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Place.Marshal(b, m, deterministic)
+}
+func (dst *Place) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Place.Merge(dst, src)
+}
+func (m *Place) XXX_Size() int {
+	return xxx_messageInfo_Place.Size(m)
+}
+func (m *Place) XXX_DiscardUnknown() {
+	xxx_messageInfo_Place.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Place proto.InternalMessageInfo
+
+func (m *Place) GetMark() uint32 {
+	if m != nil {
+		return m.Mark
+	}
+	return 0
+}
+
+func (m *Place) GetVisitedAt() int32 {
+	if m != nil {
+		return m.VisitedAt
+	}
+	return 0
+}
+
+func (m *Place) GetPlace() string {
+	if m != nil {
+		return m.Place
+	}
+	return ""
+}
+
+type Places struct {
+	Visits               []*Place `protobuf:"bytes,1,rep,name=visits,proto3" json:"visits,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Places) Reset()         { *m = Places{} }
+func (m *Places) String() string { return proto.CompactTextString(m) }
+func (*Places) ProtoMessage()    {}
+func (*Places) Descriptor() ([]byte, []int) {
+	return fileDescriptor_hlcup_fa6ade7d4881b9e7, []int{12}
+}
+func (m *Places) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Places.Unmarshal(m, b)
+}
+func (m *Places) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Places.Marshal(b, m, deterministic)
+}
+func (dst *Places) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Places.Merge(dst, src)
+}
+func (m *Places) XXX_Size() int {
+	return xxx_messageInfo_Places.Size(m)
+}
+func (m *Places) XXX_DiscardUnknown() {
+	xxx_messageInfo_Places.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Places proto.InternalMessageInfo
+
+func (m *Places) GetVisits() []*Place {
+	if m != nil {
+		return m.Visits
+	}
+	return nil
+}
+
+type LocationFilter struct {
+	FromDate             *wrappers.Int32Value  `protobuf:"bytes,1,opt,name=from_date,json=fromDate,proto3" json:"from_date,omitempty"`
+	ToDate               *wrappers.Int32Value  `protobuf:"bytes,2,opt,name=to_date,json=toDate,proto3" json:"to_date,omitempty"`
+	FromAge              *wrappers.Int32Value  `protobuf:"bytes,3,opt,name=from_age,json=fromAge,proto3" json:"from_age,omitempty"`
+	ToAge                *wrappers.Int32Value  `protobuf:"bytes,4,opt,name=to_age,json=toAge,proto3" json:"to_age,omitempty"`
+	Gender               *wrappers.StringValue `protobuf:"bytes,5,opt,name=gender,proto3" json:"gender,omitempty"`
+	AsOf                 *wrappers.Int64Value  `protobuf:"bytes,6,opt,name=as_of,json=asOf,proto3" json:"as_of,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *LocationFilter) Reset()         { *m = LocationFilter{} }
+func (m *LocationFilter) String() string { return proto.CompactTextString(m) }
+func (*LocationFilter) ProtoMessage()    {}
+func (*LocationFilter) Descriptor() ([]byte, []int) {
+	return fileDescriptor_hlcup_fa6ade7d4881b9e7, []int{13}
+}
+func (m *LocationFilter) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LocationFilter.Unmarshal(m, b)
+}
+func (m *LocationFilter) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LocationFilter.Marshal(b, m, deterministic)
+}
+func (dst *LocationFilter) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LocationFilter.Merge(dst, src)
+}
+func (m *LocationFilter) XXX_Size() int {
+	return xxx_messageInfo_LocationFilter.Size(m)
+}
+func (m *LocationFilter) XXX_DiscardUnknown() {
+	xxx_messageInfo_LocationFilter.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LocationFilter proto.InternalMessageInfo
+
+func (m *LocationFilter) GetFromDate() *wrappers.Int32Value {
+	if m != nil {
+		return m.FromDate
+	}
+	return nil
+}
+
+func (m *LocationFilter) GetToDate() *wrappers.Int32Value {
+	if m != nil {
+		return m.ToDate
+	}
+	return nil
+}
+
+func (m *LocationFilter) GetFromAge() *wrappers.Int32Value {
+	if m != nil {
+		return m.FromAge
+	}
+	return nil
+}
+
+func (m *LocationFilter) GetToAge() *wrappers.Int32Value {
+	if m != nil {
+		return m.ToAge
+	}
+	return nil
+}
+
+func (m *LocationFilter) GetGender() *wrappers.StringValue {
+	if m != nil {
+		return m.Gender
+	}
+	return nil
+}
+
+func (m *LocationFilter) GetAsOf() *wrappers.Int64Value {
+	if m != nil {
+		return m.AsOf
+	}
+	return nil
+}
+
+type GetLocationAverageMarkRequest struct {
+	Id                   uint32          `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Filter               *LocationFilter `protobuf:"bytes,2,opt,name=filter,proto3" json:"filter,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *GetLocationAverageMarkRequest) Reset()         { *m = GetLocationAverageMarkRequest{} }
+func (m *GetLocationAverageMarkRequest) String() string { return proto.CompactTextString(m) }
+func (*GetLocationAverageMarkRequest) ProtoMessage()    {}
+func (*GetLocationAverageMarkRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_hlcup_fa6ade7d4881b9e7, []int{14}
+}
+func (m *GetLocationAverageMarkRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetLocationAverageMarkRequest.Unmarshal(m, b)
+}
+func (m *GetLocationAverageMarkRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetLocationAverageMarkRequest.Marshal(b, m, deterministic)
+}
+func (dst *GetLocationAverageMarkRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetLocationAverageMarkRequest.Merge(dst, src)
+}
+func (m *GetLocationAverageMarkRequest) XXX_Size() int {
+	return xxx_messageInfo_GetLocationAverageMarkRequest.Size(m)
+}
+func (m *GetLocationAverageMarkRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetLocationAverageMarkRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetLocationAverageMarkRequest proto.InternalMessageInfo
+
+func (m *GetLocationAverageMarkRequest) GetId() uint32 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *GetLocationAverageMarkRequest) GetFilter() *LocationFilter {
+	if m != nil {
+		return m.Filter
+	}
+	return nil
+}
+
+type LocationAvgMark struct {
+	Avg                  float64  `protobuf:"fixed64,1,opt,name=avg,proto3" json:"avg,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LocationAvgMark) Reset()         { *m = LocationAvgMark{} }
+func (m *LocationAvgMark) String() string { return proto.CompactTextString(m) }
+func (*LocationAvgMark) ProtoMessage()    {}
+func (*LocationAvgMark) Descriptor() ([]byte, []int) {
+	return fileDescriptor_hlcup_fa6ade7d4881b9e7, []int{15}
+}
+func (m *LocationAvgMark) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LocationAvgMark.Unmarshal(m, b)
+}
+func (m *LocationAvgMark) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LocationAvgMark.Marshal(b, m, deterministic)
+}
+func (dst *LocationAvgMark) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LocationAvgMark.Merge(dst, src)
+}
+func (m *LocationAvgMark) XXX_Size() int {
+	return xxx_messageInfo_LocationAvgMark.Size(m)
+}
+func (m *LocationAvgMark) XXX_DiscardUnknown() {
+	xxx_messageInfo_LocationAvgMark.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LocationAvgMark proto.InternalMessageInfo
+
+func (m *LocationAvgMark) GetAvg() float64 {
+	if m != nil {
+		return m.Avg
+	}
+	return 0
+}
+
+type BulkError struct {
+	Line                 int32    `protobuf:"varint,1,opt,name=line,proto3" json:"line,omitempty"`
+	Error                string   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BulkError) Reset()         { *m = BulkError{} }
+func (m *BulkError) String() string { return proto.CompactTextString(m) }
+func (*BulkError) ProtoMessage()    {}
+func (*BulkError) Descriptor() ([]byte, []int) {
+	return fileDescriptor_hlcup_fa6ade7d4881b9e7, []int{16}
+}
+func (m *BulkError) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BulkError.Unmarshal(m, b)
+}
+func (m *BulkError) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BulkError.Marshal(b, m, deterministic)
+}
+func (dst *BulkError) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BulkError.Merge(dst, src)
+}
+func (m *BulkError) XXX_Size() int {
+	return xxx_messageInfo_BulkError.Size(m)
+}
+func (m *BulkError) XXX_DiscardUnknown() {
+	xxx_messageInfo_BulkError.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BulkError proto.InternalMessageInfo
+
+func (m *BulkError) GetLine() int32 {
+	if m != nil {
+		return m.Line
+	}
+	return 0
+}
+
+func (m *BulkError) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type BulkResult struct {
+	Accepted             int32        `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Rejected             int32        `protobuf:"varint,2,opt,name=rejected,proto3" json:"rejected,omitempty"`
+	Errors               []*BulkError `protobuf:"bytes,3,rep,name=errors,proto3" json:"errors,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *BulkResult) Reset()         { *m = BulkResult{} }
+func (m *BulkResult) String() string { return proto.CompactTextString(m) }
+func (*BulkResult) ProtoMessage()    {}
+func (*BulkResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_hlcup_fa6ade7d4881b9e7, []int{17}
+}
+func (m *BulkResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BulkResult.Unmarshal(m, b)
+}
+func (m *BulkResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BulkResult.Marshal(b, m, deterministic)
+}
+func (dst *BulkResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BulkResult.Merge(dst, src)
+}
+func (m *BulkResult) XXX_Size() int {
+	return xxx_messageInfo_BulkResult.Size(m)
+}
+func (m *BulkResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_BulkResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BulkResult proto.InternalMessageInfo
+
+func (m *BulkResult) GetAccepted() int32 {
+	if m != nil {
+		return m.Accepted
+	}
+	return 0
+}
+
+func (m *BulkResult) GetRejected() int32 {
+	if m != nil {
+		return m.Rejected
+	}
+	return 0
+}
+
+func (m *BulkResult) GetErrors() []*BulkError {
+	if m != nil {
+		return m.Errors
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*User)(nil), "hlcup.User")
+	proto.RegisterType((*Location)(nil), "hlcup.Location")
+	proto.RegisterType((*Visit)(nil), "hlcup.Visit")
+	proto.RegisterType((*GetRequest)(nil), "hlcup.GetRequest")
+	proto.RegisterType((*UpdateUserRequest)(nil), "hlcup.UpdateUserRequest")
+	proto.RegisterType((*UpdateLocationRequest)(nil), "hlcup.UpdateLocationRequest")
+	proto.RegisterType((*UpdateVisitRequest)(nil), "hlcup.UpdateVisitRequest")
+	proto.RegisterType((*Created)(nil), "hlcup.Created")
+	proto.RegisterType((*Updated)(nil), "hlcup.Updated")
+	proto.RegisterType((*PlaceFilter)(nil), "hlcup.PlaceFilter")
+	proto.RegisterType((*ListUserVisitsRequest)(nil), "hlcup.ListUserVisitsRequest")
+	proto.RegisterType((*Place)(nil), "hlcup.Place")
+	proto.RegisterType((*Places)(nil), "hlcup.Places")
+	proto.RegisterType((*LocationFilter)(nil), "hlcup.LocationFilter")
+	proto.RegisterType((*GetLocationAverageMarkRequest)(nil), "hlcup.GetLocationAverageMarkRequest")
+	proto.RegisterType((*LocationAvgMark)(nil), "hlcup.LocationAvgMark")
+	proto.RegisterType((*BulkError)(nil), "hlcup.BulkError")
+	proto.RegisterType((*BulkResult)(nil), "hlcup.BulkResult")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// HLCupClient is the client API for HLCup service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type HLCupClient interface {
+	GetUser(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*User, error)
+	CreateUser(ctx context.Context, in *User, opts ...grpc.CallOption) (*Created, error)
+	UpdateUser(ctx context.Context, in *UpdateUserRequest, opts ...grpc.CallOption) (*Updated, error)
+	ListUserVisits(ctx context.Context, in *ListUserVisitsRequest, opts ...grpc.CallOption) (*Places, error)
+	GetLocation(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Location, error)
+	CreateLocation(ctx context.Context, in *Location, opts ...grpc.CallOption) (*Created, error)
+	UpdateLocation(ctx context.Context, in *UpdateLocationRequest, opts ...grpc.CallOption) (*Updated, error)
+	GetLocationAverageMark(ctx context.Context, in *GetLocationAverageMarkRequest, opts ...grpc.CallOption) (*LocationAvgMark, error)
+	GetVisit(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Visit, error)
+	CreateVisit(ctx context.Context, in *Visit, opts ...grpc.CallOption) (*Created, error)
+	UpdateVisit(ctx context.Context, in *UpdateVisitRequest, opts ...grpc.CallOption) (*Updated, error)
+	// IngestVisits inserts streamed visits in chunks like POST /visits/bulk,
+	// errors refer to visits by their 1-based positions in the stream.
+	IngestVisits(ctx context.Context, opts ...grpc.CallOption) (HLCup_IngestVisitsClient, error)
+}
+
+type hLCupClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewHLCupClient(cc *grpc.ClientConn) HLCupClient {
+	return &hLCupClient{cc}
+}
+
+func (c *hLCupClient) GetUser(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := c.cc.Invoke(ctx, "/hlcup.HLCup/GetUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hLCupClient) CreateUser(ctx context.Context, in *User, opts ...grpc.CallOption) (*Created, error) {
+	out := new(Created)
+	err := c.cc.Invoke(ctx, "/hlcup.HLCup/CreateUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hLCupClient) UpdateUser(ctx context.Context, in *UpdateUserRequest, opts ...grpc.CallOption) (*Updated, error) {
+	out := new(Updated)
+	err := c.cc.Invoke(ctx, "/hlcup.HLCup/UpdateUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hLCupClient) ListUserVisits(ctx context.Context, in *ListUserVisitsRequest, opts ...grpc.CallOption) (*Places, error) {
+	out := new(Places)
+	err := c.cc.Invoke(ctx, "/hlcup.HLCup/ListUserVisits", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hLCupClient) GetLocation(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Location, error) {
+	out := new(Location)
+	err := c.cc.Invoke(ctx, "/hlcup.HLCup/GetLocation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hLCupClient) CreateLocation(ctx context.Context, in *Location, opts ...grpc.CallOption) (*Created, error) {
+	out := new(Created)
+	err := c.cc.Invoke(ctx, "/hlcup.HLCup/CreateLocation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hLCupClient) UpdateLocation(ctx context.Context, in *UpdateLocationRequest, opts ...grpc.CallOption) (*Updated, error) {
+	out := new(Updated)
+	err := c.cc.Invoke(ctx, "/hlcup.HLCup/UpdateLocation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hLCupClient) GetLocationAverageMark(ctx context.Context, in *GetLocationAverageMarkRequest, opts ...grpc.CallOption) (*LocationAvgMark, error) {
+	out := new(LocationAvgMark)
+	err := c.cc.Invoke(ctx, "/hlcup.HLCup/GetLocationAverageMark", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hLCupClient) GetVisit(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Visit, error) {
+	out := new(Visit)
+	err := c.cc.Invoke(ctx, "/hlcup.HLCup/GetVisit", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hLCupClient) CreateVisit(ctx context.Context, in *Visit, opts ...grpc.CallOption) (*Created, error) {
+	out := new(Created)
+	err := c.cc.Invoke(ctx, "/hlcup.HLCup/CreateVisit", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hLCupClient) UpdateVisit(ctx context.Context, in *UpdateVisitRequest, opts ...grpc.CallOption) (*Updated, error) {
+	out := new(Updated)
+	err := c.cc.Invoke(ctx, "/hlcup.HLCup/UpdateVisit", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hLCupClient) IngestVisits(ctx context.Context, opts ...grpc.CallOption) (HLCup_IngestVisitsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_HLCup_serviceDesc.Streams[0], "/hlcup.HLCup/IngestVisits", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &hLCupIngestVisitsClient{stream}
+	return x, nil
+}
+
+type HLCup_IngestVisitsClient interface {
+	Send(*Visit) error
+	CloseAndRecv() (*BulkResult, error)
+	grpc.ClientStream
+}
+
+type hLCupIngestVisitsClient struct {
+	grpc.ClientStream
+}
+
+func (x *hLCupIngestVisitsClient) Send(m *Visit) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *hLCupIngestVisitsClient) CloseAndRecv() (*BulkResult, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(BulkResult)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// HLCupServer is the server API for HLCup service.
+type HLCupServer interface {
+	GetUser(context.Context, *GetRequest) (*User, error)
+	CreateUser(context.Context, *User) (*Created, error)
+	UpdateUser(context.Context, *UpdateUserRequest) (*Updated, error)
+	ListUserVisits(context.Context, *ListUserVisitsRequest) (*Places, error)
+	GetLocation(context.Context, *GetRequest) (*Location, error)
+	CreateLocation(context.Context, *Location) (*Created, error)
+	UpdateLocation(context.Context, *UpdateLocationRequest) (*Updated, error)
+	GetLocationAverageMark(context.Context, *GetLocationAverageMarkRequest) (*LocationAvgMark, error)
+	GetVisit(context.Context, *GetRequest) (*Visit, error)
+	CreateVisit(context.Context, *Visit) (*Created, error)
+	UpdateVisit(context.Context, *UpdateVisitRequest) (*Updated, error)
+	// IngestVisits inserts streamed visits in chunks like POST /visits/bulk,
+	// errors refer to visits by their 1-based positions in the stream.
+	IngestVisits(HLCup_IngestVisitsServer) error
+}
+
+func RegisterHLCupServer(s *grpc.Server, srv HLCupServer) {
+	s.RegisterService(&_HLCup_serviceDesc, srv)
+}
+
+func _HLCup_GetUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HLCupServer).GetUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hlcup.HLCup/GetUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HLCupServer).GetUser(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HLCup_CreateUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(User)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HLCupServer).CreateUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hlcup.HLCup/CreateUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HLCupServer).CreateUser(ctx, req.(*User))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HLCup_UpdateUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HLCupServer).UpdateUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hlcup.HLCup/UpdateUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HLCupServer).UpdateUser(ctx, req.(*UpdateUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HLCup_ListUserVisits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListUserVisitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HLCupServer).ListUserVisits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hlcup.HLCup/ListUserVisits",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HLCupServer).ListUserVisits(ctx, req.(*ListUserVisitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HLCup_GetLocation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HLCupServer).GetLocation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hlcup.HLCup/GetLocation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HLCupServer).GetLocation(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HLCup_CreateLocation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Location)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HLCupServer).CreateLocation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hlcup.HLCup/CreateLocation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HLCupServer).CreateLocation(ctx, req.(*Location))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HLCup_UpdateLocation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateLocationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HLCupServer).UpdateLocation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hlcup.HLCup/UpdateLocation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HLCupServer).UpdateLocation(ctx, req.(*UpdateLocationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HLCup_GetLocationAverageMark_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLocationAverageMarkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HLCupServer).GetLocationAverageMark(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hlcup.HLCup/GetLocationAverageMark",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HLCupServer).GetLocationAverageMark(ctx, req.(*GetLocationAverageMarkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HLCup_GetVisit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HLCupServer).GetVisit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hlcup.HLCup/GetVisit",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HLCupServer).GetVisit(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HLCup_CreateVisit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Visit)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HLCupServer).CreateVisit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hlcup.HLCup/CreateVisit",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HLCupServer).CreateVisit(ctx, req.(*Visit))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HLCup_UpdateVisit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateVisitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HLCupServer).UpdateVisit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hlcup.HLCup/UpdateVisit",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HLCupServer).UpdateVisit(ctx, req.(*UpdateVisitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HLCup_IngestVisits_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(HLCupServer).IngestVisits(&hLCupIngestVisitsServer{stream})
+}
+
+type HLCup_IngestVisitsServer interface {
+	SendAndClose(*BulkResult) error
+	Recv() (*Visit, error)
+	grpc.ServerStream
+}
+
+type hLCupIngestVisitsServer struct {
+	grpc.ServerStream
+}
+
+func (x *hLCupIngestVisitsServer) SendAndClose(m *BulkResult) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *hLCupIngestVisitsServer) Recv() (*Visit, error) {
+	m := new(Visit)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var _HLCup_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "hlcup.HLCup",
+	HandlerType: (*HLCupServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetUser",
+			Handler:    _HLCup_GetUser_Handler,
+		},
+		{
+			MethodName: "CreateUser",
+			Handler:    _HLCup_CreateUser_Handler,
+		},
+		{
+			MethodName: "UpdateUser",
+			Handler:    _HLCup_UpdateUser_Handler,
+		},
+		{
+			MethodName: "ListUserVisits",
+			Handler:    _HLCup_ListUserVisits_Handler,
+		},
+		{
+			MethodName: "GetLocation",
+			Handler:    _HLCup_GetLocation_Handler,
+		},
+		{
+			MethodName: "CreateLocation",
+			Handler:    _HLCup_CreateLocation_Handler,
+		},
+		{
+			MethodName: "UpdateLocation",
+			Handler:    _HLCup_UpdateLocation_Handler,
+		},
+		{
+			MethodName: "GetLocationAverageMark",
+			Handler:    _HLCup_GetLocationAverageMark_Handler,
+		},
+		{
+			MethodName: "GetVisit",
+			Handler:    _HLCup_GetVisit_Handler,
+		},
+		{
+			MethodName: "CreateVisit",
+			Handler:    _HLCup_CreateVisit_Handler,
+		},
+		{
+			MethodName: "UpdateVisit",
+			Handler:    _HLCup_UpdateVisit_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "IngestVisits",
+			Handler:       _HLCup_IngestVisits_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "api/hlcup.proto",
+}
+
+func init() { proto.RegisterFile("api/hlcup.proto", fileDescriptor_hlcup_fa6ade7d4881b9e7) }
+
+var fileDescriptor_hlcup_fa6ade7d4881b9e7 = []byte{
+	// 1004 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x56, 0xdd, 0x6e, 0xdb, 0x46,
+	0x13, 0x85, 0x28, 0x91, 0x92, 0x46, 0x89, 0x1c, 0x2f, 0x3e, 0x07, 0x8c, 0xbe, 0xa4, 0x0d, 0x98,
+	0x5c, 0x38, 0x75, 0x2b, 0xc7, 0x8a, 0x6b, 0xb8, 0x2d, 0x5a, 0xc0, 0x49, 0x5a, 0x27, 0x80, 0x9b,
+	0x06, 0x4a, 0xed, 0x8b, 0x5e, 0x54, 0x58, 0x8b, 0x23, 0x86, 0x35, 0x45, 0xb2, 0xbb, 0x4b, 0x17,
+	0x79, 0x85, 0xbe, 0x41, 0x2f, 0xfb, 0x34, 0xed, 0x03, 0xf4, 0x81, 0x8a, 0xfd, 0x21, 0x45, 0xfd,
+	0x25, 0xb4, 0x6f, 0x7a, 0xa7, 0xe5, 0x9c, 0x33, 0xbb, 0x33, 0x73, 0x66, 0x46, 0xb0, 0x41, 0xd3,
+	0x70, 0xf7, 0x6d, 0x34, 0xce, 0xd2, 0x7e, 0xca, 0x12, 0x91, 0x10, 0x5b, 0x1d, 0x7a, 0x1f, 0x05,
+	0x49, 0x12, 0x44, 0xb8, 0xab, 0x3e, 0x9e, 0x67, 0x93, 0xdd, 0xdf, 0x18, 0x4d, 0x53, 0x64, 0x5c,
+	0xc3, 0xbc, 0xdf, 0xeb, 0xd0, 0x38, 0xe5, 0xc8, 0xc8, 0xa7, 0x60, 0x85, 0xbe, 0x5b, 0xbb, 0x5f,
+	0xdb, 0xee, 0x0c, 0xee, 0xf6, 0x35, 0xab, 0x9f, 0xb3, 0xfa, 0xa7, 0x2f, 0x63, 0xf1, 0x64, 0x70,
+	0x46, 0xa3, 0x0c, 0x87, 0x56, 0xe8, 0x93, 0x01, 0xd8, 0x38, 0xa5, 0x61, 0xe4, 0x5a, 0x6b, 0x08,
+	0x6f, 0x04, 0x0b, 0xe3, 0x40, 0x13, 0x34, 0x94, 0x7c, 0x05, 0x30, 0x09, 0x19, 0x17, 0xa3, 0x98,
+	0x4e, 0xd1, 0xad, 0x57, 0x20, 0xb6, 0x15, 0xfe, 0x15, 0x9d, 0x22, 0xf9, 0x02, 0xda, 0x11, 0xcd,
+	0xb9, 0x8d, 0x0a, 0xdc, 0x56, 0x44, 0x0d, 0x75, 0x1f, 0x9c, 0x00, 0x63, 0x1f, 0x99, 0x6b, 0x57,
+	0xe0, 0x19, 0x2c, 0xf9, 0x12, 0xe0, 0x3c, 0x64, 0xe2, 0xed, 0xc8, 0xa7, 0x02, 0x5d, 0x47, 0x31,
+	0xff, 0xbf, 0xc4, 0x2c, 0xa5, 0xa5, 0xad, 0xe0, 0xcf, 0xa9, 0x40, 0x72, 0x00, 0xcd, 0x4b, 0x64,
+	0x3c, 0x4c, 0x62, 0xb7, 0xf9, 0x9e, 0x84, 0x1e, 0xec, 0x6b, 0x66, 0x0e, 0xf6, 0xfe, 0xb6, 0xa0,
+	0x75, 0x92, 0x8c, 0xa9, 0x08, 0x93, 0xf8, 0xea, 0x05, 0x49, 0x23, 0x3a, 0xc6, 0x6a, 0x05, 0x51,
+	0x50, 0xf9, 0xcc, 0x71, 0x92, 0xc5, 0x82, 0xbd, 0xab, 0x54, 0x8d, 0x1c, 0x4c, 0x1e, 0x43, 0x63,
+	0x1c, 0x8a, 0x77, 0x95, 0xca, 0xa0, 0x90, 0xe4, 0x10, 0x5a, 0x7e, 0xc8, 0x05, 0x8d, 0xc7, 0xe8,
+	0xda, 0x15, 0x22, 0x2a, 0xd0, 0xe5, 0x54, 0x3a, 0x57, 0x49, 0xe5, 0x5f, 0x16, 0xd8, 0x67, 0x21,
+	0x0f, 0xc5, 0x15, 0xf3, 0x78, 0x08, 0xad, 0xc8, 0x54, 0xc0, 0xb5, 0x2a, 0x70, 0x0a, 0xb4, 0xcc,
+	0x4a, 0xc6, 0x91, 0xb9, 0xf5, 0x0a, 0x2c, 0x85, 0x94, 0x12, 0xbb, 0x94, 0x4f, 0x44, 0x7f, 0x44,
+	0x85, 0xdb, 0xa8, 0x20, 0x31, 0x03, 0x3f, 0x12, 0xf2, 0xb6, 0x29, 0x65, 0x17, 0x95, 0xb2, 0xa9,
+	0x90, 0xd7, 0xce, 0xe4, 0x2b, 0x80, 0x63, 0x14, 0x43, 0xfc, 0x35, 0x43, 0x2e, 0x48, 0xb7, 0xc8,
+	0xe6, 0x4d, 0x95, 0xaf, 0xc7, 0x60, 0x53, 0x3e, 0x4a, 0x26, 0xae, 0xb5, 0xfe, 0xf9, 0xb9, 0xcb,
+	0x06, 0xe5, 0x3f, 0x4c, 0xbc, 0xe7, 0xb0, 0x79, 0x9a, 0xca, 0xa6, 0x92, 0x63, 0x67, 0x9d, 0xdb,
+	0x8f, 0x4d, 0x32, 0xb5, 0xd7, 0x4e, 0x5f, 0x4f, 0x36, 0xc5, 0x50, 0x06, 0xef, 0x47, 0xd8, 0xd2,
+	0x5e, 0xf2, 0x7e, 0x59, 0xe7, 0x69, 0x67, 0xa9, 0xa0, 0x1b, 0xc6, 0x5b, 0xc1, 0x2c, 0x00, 0xde,
+	0x0b, 0x20, 0xda, 0xab, 0x92, 0xce, 0x3a, 0x97, 0x1e, 0xd8, 0xaa, 0x10, 0xc6, 0xdf, 0x0d, 0xe3,
+	0x4f, 0x73, 0xb4, 0xc9, 0xbb, 0x03, 0xcd, 0x67, 0x0c, 0xa9, 0x40, 0x7f, 0x91, 0xee, 0x3d, 0x80,
+	0xa6, 0xbe, 0xc4, 0x27, 0xee, 0xac, 0x26, 0xd2, 0xde, 0x98, 0x65, 0xfd, 0x4f, 0x0b, 0x3a, 0xaf,
+	0x65, 0x97, 0x7e, 0x17, 0x46, 0x02, 0x19, 0x39, 0x84, 0xf6, 0x84, 0x25, 0x53, 0x3d, 0x8d, 0x6a,
+	0x1f, 0x96, 0x4a, 0x4b, 0xa2, 0xd5, 0x30, 0xda, 0x87, 0xa6, 0x48, 0x34, 0xcf, 0xfa, 0x30, 0xcf,
+	0x11, 0x49, 0x3e, 0xc2, 0xae, 0x35, 0x1b, 0xca, 0x9d, 0xde, 0xb8, 0x52, 0xa7, 0x17, 0x4a, 0xb2,
+	0xab, 0x2a, 0xe9, 0x0d, 0x6c, 0x9d, 0x84, 0x5c, 0x48, 0x55, 0xa8, 0xdc, 0xf3, 0x75, 0x05, 0xfb,
+	0x04, 0x9c, 0x89, 0x4a, 0xa3, 0xc9, 0x00, 0x31, 0x15, 0x2b, 0x25, 0x78, 0x68, 0x10, 0xde, 0x6b,
+	0xb0, 0xd5, 0x67, 0x42, 0x4c, 0x87, 0x69, 0x37, 0xea, 0x37, 0xb9, 0x37, 0xd7, 0xb1, 0xd2, 0x99,
+	0x5d, 0x6e, 0xca, 0xff, 0xe5, 0x43, 0x58, 0xa6, 0xac, 0x6d, 0xc6, 0xac, 0xd7, 0x07, 0x47, 0x79,
+	0xe4, 0xe4, 0x21, 0x38, 0x0a, 0xcc, 0xdd, 0xda, 0xfd, 0x7a, 0x49, 0x39, 0xca, 0x3c, 0x34, 0x36,
+	0xef, 0x1f, 0x0b, 0xba, 0xb9, 0x36, 0xff, 0xb3, 0xea, 0x2b, 0x0f, 0x23, 0x1a, 0xe4, 0x8b, 0xfa,
+	0xbd, 0xb4, 0xa6, 0x04, 0x1f, 0x05, 0x48, 0x06, 0xe0, 0x88, 0x44, 0xb1, 0x2a, 0x4c, 0x33, 0x5b,
+	0x24, 0x47, 0xc1, 0x75, 0xd7, 0x73, 0xa1, 0x16, 0xa7, 0xaa, 0x5a, 0x7e, 0x86, 0x7b, 0xc7, 0x28,
+	0xf2, 0xc4, 0x1e, 0x5d, 0x22, 0xa3, 0x01, 0x7e, 0x4f, 0xd9, 0xc5, 0x3a, 0xd5, 0x7c, 0xb6, 0xa0,
+	0x9a, 0xad, 0x85, 0xb9, 0xb1, 0x20, 0x9c, 0x07, 0xb0, 0x31, 0x73, 0x1e, 0x48, 0xc7, 0xe4, 0x16,
+	0xd4, 0xe9, 0x65, 0xa0, 0x5c, 0xd6, 0x86, 0xf2, 0xa7, 0xf7, 0x39, 0xb4, 0x9f, 0x66, 0xd1, 0xc5,
+	0xb7, 0x8c, 0x25, 0x4c, 0x2a, 0x2c, 0x0a, 0x63, 0x5d, 0x50, 0x7b, 0xa8, 0x7e, 0x4b, 0x09, 0xa1,
+	0x34, 0xaa, 0x3b, 0xdb, 0x43, 0x7d, 0xf0, 0x62, 0x00, 0x49, 0x1b, 0x22, 0xcf, 0x22, 0x41, 0x7a,
+	0xd0, 0xa2, 0xe3, 0x31, 0xa6, 0x02, 0x7d, 0xc3, 0x2d, 0xce, 0xd2, 0xc6, 0xf0, 0x17, 0x1c, 0x4b,
+	0x9b, 0xd6, 0x67, 0x71, 0x26, 0xdb, 0xe0, 0x28, 0x77, 0xdc, 0xad, 0x2b, 0xf9, 0xdd, 0x32, 0x01,
+	0x15, 0x2f, 0x1a, 0x1a, 0xfb, 0xe0, 0x0f, 0x1b, 0xec, 0x17, 0x27, 0xcf, 0xb2, 0x94, 0x3c, 0x82,
+	0xe6, 0x31, 0xaa, 0x16, 0x23, 0x9b, 0x06, 0x3e, 0xdb, 0x06, 0xbd, 0xf2, 0x60, 0x26, 0x8f, 0x00,
+	0xf4, 0xc8, 0x53, 0xa7, 0xb2, 0xa9, 0xd7, 0x35, 0x87, 0x7c, 0x24, 0x1e, 0x00, 0xcc, 0x76, 0x00,
+	0x71, 0x73, 0xe8, 0xe2, 0x5a, 0xe8, 0x75, 0xe7, 0x2c, 0x3e, 0xf9, 0x1a, 0xba, 0xf3, 0x1d, 0x4f,
+	0xee, 0xe6, 0x45, 0x59, 0x35, 0x08, 0x7a, 0x37, 0xcb, 0x0d, 0xc6, 0xc9, 0x1e, 0x74, 0x4a, 0x12,
+	0x58, 0x15, 0xd0, 0xe2, 0x6e, 0x20, 0x7b, 0xd0, 0xd5, 0x8f, 0x2e, 0xbe, 0x2c, 0x42, 0x96, 0x82,
+	0xfb, 0x06, 0xba, 0xf3, 0xab, 0xa9, 0x78, 0xe4, 0xca, 0x8d, 0xb5, 0x14, 0xe4, 0x19, 0xdc, 0x5e,
+	0x2d, 0x54, 0xf2, 0x70, 0xf6, 0xe0, 0xf5, 0x3a, 0xee, 0xdd, 0x5e, 0x78, 0x60, 0xae, 0xc6, 0x1d,
+	0x68, 0x1d, 0xa3, 0xd0, 0x7f, 0x8a, 0x56, 0x84, 0x3e, 0xb7, 0xc6, 0xc8, 0x0e, 0x74, 0x74, 0x3c,
+	0xfa, 0x38, 0x67, 0x5c, 0x8a, 0xf8, 0x10, 0x3a, 0xa5, 0xb5, 0x49, 0xee, 0xcc, 0x05, 0x54, 0x5e,
+	0xa5, 0x4b, 0xb1, 0xee, 0xc1, 0x8d, 0x97, 0x71, 0x80, 0x5c, 0x98, 0x72, 0xce, 0xdf, 0xb3, 0x59,
+	0x12, 0xa8, 0xd6, 0xfe, 0x76, 0xed, 0xa9, 0xfd, 0x53, 0x9d, 0xa6, 0xe1, 0xb9, 0xa3, 0x3a, 0xfd,
+	0xc9, 0xbf, 0x03, 0x00, 0x0f, 0x73, 0xdf, 0x43, 0xf9, 0x0c, 0x00, 0x00,
+}
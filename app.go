@@ -1,23 +1,46 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/schema"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 var emptyJSON = map[string]string{}
 
+const requestIDHeader = "X-Request-ID"
+
+// createdJSON contains id of the created entity.
+type createdJSON struct {
+	ID *uint32 `json:"id"`
+}
+
 // App contains server router and database configuration.
 type App struct {
 	Router   *mux.Router
 	Database *Database
+	Config   *Config
+
+	idempotency *idempotencyStore
+	changes     *changeFeed
+	webhooks    *webhookDispatcher
+	openAPI     []byte
 }
 
 // Initialize the server with specified configurations.
 func (a *App) Initialize(c *Config) error {
+	a.Config = c
 	a.Database = new(Database)
 	if err := a.Database.Initialize(c.DBConfig); err != nil {
 		return err
@@ -26,44 +49,282 @@ func (a *App) Initialize(c *Config) error {
 		return nil
 	}
 
+	ttl := time.Duration(c.IdempotencyTTL) * time.Second
+	a.idempotency = newIdempotencyStore(ttl, c.IdempotencyCapacity)
+	a.changes = newChangeFeed()
+
+	for _, webhook := range c.Webhooks {
+		if err := a.Database.InsertWebhook(webhook); err != nil {
+			return err
+		}
+	}
+	a.webhooks = newWebhookDispatcher(a.Database)
+	go a.webhooks.run(a.changes.subscribe())
+
 	a.Router = mux.NewRouter()
+	a.Router.Use(requestIDMiddleware)
 	a.initializeRoutes()
 
+	openAPI, err := openAPIDocument()
+	if err != nil {
+		return err
+	}
+	a.openAPI = openAPI
+
 	return nil
 }
 
 func (a *App) initializeRoutes() {
+	a.Router.HandleFunc("/users", a.getUsers).Methods("GET")
 	a.Router.HandleFunc("/users/{id:[0-9]+}", a.getUser).Methods("GET")
+	a.Router.HandleFunc("/users/by-email/{email}", a.getUserByEmail).Methods("GET")
+	a.Router.HandleFunc("/users/search", a.searchUsers).Methods("GET")
 	a.Router.HandleFunc("/users/{id:[0-9]+}/visits", a.getUserVisits).Methods("GET")
+	a.Router.HandleFunc("/users/{id:[0-9]+}/avg", a.getUserAverageMark).Methods("GET")
+	a.Router.HandleFunc("/users/{id:[0-9]+}/stats", a.getUserStats).Methods("GET")
+	a.Router.HandleFunc("/users/{id:[0-9]+}/recommendations", a.getUserRecommendations).Methods("GET")
+	a.Router.HandleFunc("/users/{id:[0-9]+}/history", a.getHistory(usersTableName)).Methods("GET")
 	a.Router.HandleFunc("/users/{id:[0-9]+}", a.updateUser).Methods("POST")
-	a.Router.HandleFunc("/users/new", a.createUser).Methods("POST")
+	a.Router.HandleFunc("/users/new", a.idempotency.wrap(a.createUser)).Methods("POST")
+	a.Router.HandleFunc("/users/bulk", a.bulkUsers).Methods("POST")
+	a.Router.HandleFunc("/users/{id:[0-9]+}", a.deleteUser).Methods("DELETE")
 
+	a.Router.HandleFunc("/locations", a.getLocations).Methods("GET")
+	a.Router.HandleFunc("/locations/top", a.getTopLocations).Methods("GET")
+	a.Router.HandleFunc("/locations/search", a.searchLocations).Methods("GET")
 	a.Router.HandleFunc("/locations/{id:[0-9]+}", a.getLocation).Methods("GET")
 	a.Router.HandleFunc("/locations/{id:[0-9]+}/avg", a.getLocationAverageMark).Methods("GET")
+	a.Router.HandleFunc("/locations/{id:[0-9]+}/visits", a.getLocationVisits).Methods("GET")
+	a.Router.HandleFunc("/locations/{id:[0-9]+}/stats", a.getLocationStats).Methods("GET")
+	a.Router.HandleFunc("/locations/{id:[0-9]+}/timeline", a.getLocationTimeline).Methods("GET")
+	a.Router.HandleFunc("/locations/{id:[0-9]+}/related", a.getRelatedLocations).Methods("GET")
+	a.Router.HandleFunc("/locations/{id:[0-9]+}/history", a.getHistory(locationsTableName)).Methods("GET")
 	a.Router.HandleFunc("/locations/{id:[0-9]+}", a.updateLocation).Methods("POST")
-	a.Router.HandleFunc("/locations/new", a.createLocation).Methods("POST")
+	a.Router.HandleFunc("/locations/new", a.idempotency.wrap(a.createLocation)).Methods("POST")
+	a.Router.HandleFunc("/locations/bulk", a.bulkLocations).Methods("POST")
+	a.Router.HandleFunc("/locations/{id:[0-9]+}", a.deleteLocation).Methods("DELETE")
+
+	a.Router.HandleFunc("/countries", a.getCountries).Methods("GET")
+	a.Router.HandleFunc("/countries/{country}/avg", a.getCountryAverageMark).Methods("GET")
+	a.Router.HandleFunc("/cities/{city}/avg", a.getCityAverageMark).Methods("GET")
 
+	a.Router.HandleFunc("/batch", a.applyBatch).Methods("POST")
+	a.Router.HandleFunc("/export/{entity}", a.export).Methods("GET")
+	a.Router.HandleFunc("/changes", a.getChanges).Methods("GET")
+
+	a.Router.HandleFunc("/admin/webhooks", a.admin(a.getWebhooks)).Methods("GET")
+	a.Router.HandleFunc("/admin/webhooks", a.admin(a.createWebhook)).Methods("POST")
+	a.Router.HandleFunc("/admin/webhooks/{id:[0-9]+}", a.admin(a.deleteWebhook)).Methods("DELETE")
+
+	a.Router.HandleFunc("/visits", a.getVisits).Methods("GET")
 	a.Router.HandleFunc("/visits/{id:[0-9]+}", a.getVisit).Methods("GET")
+	a.Router.HandleFunc("/visits/{id:[0-9]+}/history", a.getHistory(visitsTableName)).Methods("GET")
 	a.Router.HandleFunc("/visits/{id:[0-9]+}", a.updateVisit).Methods("POST")
-	a.Router.HandleFunc("/visits/new", a.createVisit).Methods("POST")
+	a.Router.HandleFunc("/visits/new", a.idempotency.wrap(a.createVisit)).Methods("POST")
+	a.Router.HandleFunc("/visits/bulk", a.bulkVisits).Methods("POST")
+	a.Router.HandleFunc("/visits/{id:[0-9]+}", a.deleteVisit).Methods("DELETE")
+
+	a.Router.HandleFunc("/openapi.json", a.getOpenAPI).Methods("GET")
 }
 
-// Run the server on specified address.
+// Run the server on specified address along with gRPC server, if configured.
 func (a *App) Run(addr string) {
+	if grpcAddr := a.Config.GetGRPCAddr(); grpcAddr != "" {
+		go func() {
+			log.Fatal(a.serveGRPC(grpcAddr))
+		}()
+	}
+
 	log.Fatal(http.ListenAndServe(addr, a.Router))
 }
 
+// requestIDMiddleware assigns id to requests that come without one
+// and sends it back in the response.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if id == "" {
+			id = newRequestID()
+			r.Header.Set(requestIDHeader, id)
+		}
+
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// admin returns handler which serves only requests authorized by the admin
+// token of configuration. Admin API is disabled when the token is not set.
+func (a *App) admin(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.Config.AdminToken == "" {
+			http.Error(w, "admin API is disabled", http.StatusForbidden)
+			return
+		}
+
+		auth := r.Header.Get("Authorization")
+		token := strings.TrimPrefix(auth, "Bearer ")
+		if token == auth || subtle.ConstantTimeCompare([]byte(token), []byte(a.Config.AdminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "invalid admin token", http.StatusUnauthorized)
+			return
+		}
+
+		h(w, r)
+	}
+}
+
+// newRequestID returns random request id.
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		log.Println(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// database returns database attributing changes to the request.
+func (a *App) database(r *http.Request) *Database {
+	return a.Database.Request(r.Header.Get(requestIDHeader))
+}
+
+// errorStatus returns HTTP status code corresponding to the database error.
+func errorStatus(err error) int {
+	switch err {
+	case ErrNotFound:
+		return http.StatusNotFound
+	case ErrConflict:
+		return http.StatusConflict
+	case ErrDuplicate:
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// batchResult returns batch operation status corresponding to its error.
+func batchResult(err error) *BatchResult {
+	switch err.(type) {
+	case nil:
+		return &BatchResult{Status: http.StatusOK}
+	case errBadOperation:
+		return &BatchResult{Status: http.StatusBadRequest, Error: err.Error()}
+	}
+
+	if err == ErrRolledBack {
+		return &BatchResult{Status: http.StatusFailedDependency, Error: err.Error()}
+	}
+	return &BatchResult{Status: errorStatus(err), Error: err.Error()}
+}
+
+// writeDeleteResult responds to the delete request according to its result.
+func writeDeleteResult(w http.ResponseWriter, err error) {
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(emptyJSON); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (a *App) getUsers(w http.ResponseWriter, r *http.Request) {
+	filter := new(UserListFilter)
+	decoder := schema.NewDecoder()
+	if err := decoder.Decode(filter, r.URL.Query()); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	users, err := a.Database.GetUsers(filter)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(users); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// bulkInsert inserts rows read from newline-delimited JSON request body
+// in chunks and responds with numbers of accepted and rejected lines.
+func (a *App) bulkInsert(w http.ResponseWriter, r *http.Request, newRow func() interface{}) {
+	inserter := newBulkInserter(a.database(r), a.changes)
+
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(make([]byte, bufio.MaxScanTokenSize), maxBulkLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		row := newRow()
+		if err := json.Unmarshal(data, row); err != nil {
+			inserter.result.reject(line, err)
+			continue
+		}
+
+		if err := inserter.insert(line, row); err != nil {
+			log.Println(err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := inserter.flush(); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(inserter.result); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
 func (a *App) getUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, _ := vars["id"]
 
-	user, err := a.Database.GetUser(id)
+	filter := new(AsOfFilter)
+	decoder := schema.NewDecoder()
+	decoder.IgnoreUnknownKeys(true)
+	if err := decoder.Decode(filter, r.URL.Query()); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, err := a.Database.GetUser(id, filter.AsOf)
 	if err != nil {
 		log.Println(err)
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
+	if checkNotModified(w, r, user.Version, user.UpdatedAt) {
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(user); err != nil {
 		log.Println(err)
@@ -72,104 +333,148 @@ func (a *App) getUser(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (a *App) getUserVisits(w http.ResponseWriter, r *http.Request) {
+func (a *App) getUserByEmail(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	id, _ := vars["id"]
+	email, _ := vars["email"]
 
-	filter := new(PlaceFilter)
+	filter := new(AsOfFilter)
 	decoder := schema.NewDecoder()
+	decoder.IgnoreUnknownKeys(true)
 	if err := decoder.Decode(filter, r.URL.Query()); err != nil {
 		log.Println(err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	places, err := a.Database.GetUserVisits(id, filter)
+	user, err := a.Database.GetUserByEmail(email, filter.AsOf)
 	if err != nil {
 		log.Println(err)
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
+	if checkNotModified(w, r, user.Version, user.UpdatedAt) {
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(places); err != nil {
+	if err := json.NewEncoder(w).Encode(user); err != nil {
 		log.Println(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
 
-func (a *App) createUser(w http.ResponseWriter, r *http.Request) {
-	user := new(User)
-	if err := json.NewDecoder(r.Body).Decode(user); err != nil {
+func (a *App) searchUsers(w http.ResponseWriter, r *http.Request) {
+	filter := new(UserSearchFilter)
+	decoder := schema.NewDecoder()
+	if err := decoder.Decode(filter, r.URL.Query()); err != nil {
 		log.Println(err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if err := a.Database.InsertUser(user); err != nil {
+	users, err := a.Database.SearchUsers(filter)
+	if err != nil {
 		log.Println(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(emptyJSON); err != nil {
+	if err := json.NewEncoder(w).Encode(users); err != nil {
 		log.Println(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
 
-func (a *App) updateUser(w http.ResponseWriter, r *http.Request) {
+func (a *App) getUserVisits(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, _ := vars["id"]
 
-	user := new(User)
-	if err := json.NewDecoder(r.Body).Decode(user); err != nil {
+	filter := new(UserVisitsFilter)
+	decoder := schema.NewDecoder()
+	if err := decoder.Decode(filter, r.URL.Query()); err != nil {
 		log.Println(err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if err := a.Database.UpdateUser(id, user); err != nil {
+	places, err := a.Database.GetUserVisits(id, filter)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(places); err != nil {
 		log.Println(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+}
+
+func (a *App) getUserAverageMark(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, _ := vars["id"]
+
+	filter := new(PlaceFilter)
+	decoder := schema.NewDecoder()
+	if err := decoder.Decode(filter, r.URL.Query()); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	avg, err := a.Database.GetUserAverageMark(id, filter)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(emptyJSON); err != nil {
+	if err := json.NewEncoder(w).Encode(avg); err != nil {
 		log.Println(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
 
-func (a *App) getLocation(w http.ResponseWriter, r *http.Request) {
+func (a *App) getUserStats(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, _ := vars["id"]
 
-	location, err := a.Database.GetLocation(id)
+	filter := new(PlaceFilter)
+	decoder := schema.NewDecoder()
+	if err := decoder.Decode(filter, r.URL.Query()); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stats, err := a.Database.GetUserStats(id, filter)
 	if err != nil {
 		log.Println(err)
-		http.Error(w, err.Error(), http.StatusNotFound)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(location); err != nil {
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
 		log.Println(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
 
-func (a *App) getLocationAverageMark(w http.ResponseWriter, r *http.Request) {
+func (a *App) getUserRecommendations(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, _ := vars["id"]
 
-	filter := new(LocationFilter)
+	filter := new(RecommendationFilter)
 	decoder := schema.NewDecoder()
 	if err := decoder.Decode(filter, r.URL.Query()); err != nil {
 		log.Println(err)
@@ -177,7 +482,7 @@ func (a *App) getLocationAverageMark(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	avg, err := a.Database.GetLocationAverageMark(id, filter)
+	locations, err := a.Database.GetUserRecommendations(id, filter)
 	if err != nil {
 		log.Println(err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -185,52 +490,68 @@ func (a *App) getLocationAverageMark(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(avg); err != nil {
+	if err := json.NewEncoder(w).Encode(locations); err != nil {
 		log.Println(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
 
-func (a *App) createLocation(w http.ResponseWriter, r *http.Request) {
-	location := new(Location)
-	if err := json.NewDecoder(r.Body).Decode(location); err != nil {
+func (a *App) createUser(w http.ResponseWriter, r *http.Request) {
+	user := new(User)
+	if err := json.NewDecoder(r.Body).Decode(user); err != nil {
 		log.Println(err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if err := a.Database.InsertLocation(location); err != nil {
+	if err := a.database(r).InsertUser(user); err != nil {
 		log.Println(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), errorStatus(err))
 		return
 	}
+	a.changes.publish()
 
+	w.Header().Set("Location", fmt.Sprintf("/users/%d", *user.ID))
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(emptyJSON); err != nil {
+	if err := json.NewEncoder(w).Encode(createdJSON{user.ID}); err != nil {
 		log.Println(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
 
-func (a *App) updateLocation(w http.ResponseWriter, r *http.Request) {
+func (a *App) bulkUsers(w http.ResponseWriter, r *http.Request) {
+	a.bulkInsert(w, r, func() interface{} { return new(User) })
+}
+
+func (a *App) updateUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, _ := vars["id"]
 
-	location := new(Location)
-	if err := json.NewDecoder(r.Body).Decode(location); err != nil {
+	user := new(User)
+	if err := json.NewDecoder(r.Body).Decode(user); err != nil {
 		log.Println(err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if err := a.Database.UpdateLocation(id, location); err != nil {
+	version, err := expectedVersion(r, user.Version)
+	if err != nil {
 		log.Println(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	user.Version = version
+
+	if err := a.database(r).UpdateUser(id, user); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), errorStatus(err))
 		return
 	}
+	a.changes.publish()
 
+	w.Header().Set("ETag", entityTag(*user.Version))
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(emptyJSON); err != nil {
 		log.Println(err)
@@ -239,68 +560,763 @@ func (a *App) updateLocation(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (a *App) getVisit(w http.ResponseWriter, r *http.Request) {
+func (a *App) deleteUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, _ := vars["id"]
 
-	visit, err := a.Database.GetVisit(id)
+	cascade := a.Config.DeletePolicy == deletePolicyCascade
+	writeDeleteResult(w, a.database(r).DeleteUser(id, cascade))
+}
+
+func (a *App) getLocations(w http.ResponseWriter, r *http.Request) {
+	filter := new(LocationListFilter)
+	decoder := schema.NewDecoder()
+	if err := decoder.Decode(filter, r.URL.Query()); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	locations, err := a.Database.GetLocations(filter)
 	if err != nil {
 		log.Println(err)
-		http.Error(w, err.Error(), http.StatusNotFound)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(visit); err != nil {
+	if err := json.NewEncoder(w).Encode(locations); err != nil {
 		log.Println(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
 
-func (a *App) createVisit(w http.ResponseWriter, r *http.Request) {
-	visit := new(Visit)
-	if err := json.NewDecoder(r.Body).Decode(visit); err != nil {
+func (a *App) getLocation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, _ := vars["id"]
+
+	filter := new(AsOfFilter)
+	decoder := schema.NewDecoder()
+	decoder.IgnoreUnknownKeys(true)
+	if err := decoder.Decode(filter, r.URL.Query()); err != nil {
 		log.Println(err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if err := a.Database.InsertVisit(visit); err != nil {
+	location, err := a.Database.GetLocation(id, filter.AsOf)
+	if err != nil {
 		log.Println(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if checkNotModified(w, r, location.Version, location.UpdatedAt) {
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(emptyJSON); err != nil {
+	if err := json.NewEncoder(w).Encode(location); err != nil {
 		log.Println(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
 
-func (a *App) updateVisit(w http.ResponseWriter, r *http.Request) {
+func (a *App) getLocationAverageMark(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, _ := vars["id"]
 
-	visit := new(Visit)
-	if err := json.NewDecoder(r.Body).Decode(visit); err != nil {
+	filter := new(LocationAvgFilter)
+	decoder := schema.NewDecoder()
+	if err := decoder.Decode(filter, r.URL.Query()); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	avg, err := a.Database.GetLocationAverageMark(id, filter)
+	if err != nil {
 		log.Println(err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if err := a.Database.UpdateVisit(id, visit); err != nil {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(avg); err != nil {
 		log.Println(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+}
+
+func (a *App) getTopLocations(w http.ResponseWriter, r *http.Request) {
+	filter := new(LocationTopFilter)
+	decoder := schema.NewDecoder()
+	if err := decoder.Decode(filter, r.URL.Query()); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	locations, err := a.Database.GetTopLocations(filter)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(emptyJSON); err != nil {
+	if err := json.NewEncoder(w).Encode(locations); err != nil {
 		log.Println(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
+
+func (a *App) searchLocations(w http.ResponseWriter, r *http.Request) {
+	filter := new(LocationSearchFilter)
+	decoder := schema.NewDecoder()
+	if err := decoder.Decode(filter, r.URL.Query()); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	locations, err := a.Database.SearchLocations(filter)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(locations); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (a *App) getLocationVisits(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, _ := vars["id"]
+
+	filter := new(LocationFilter)
+	decoder := schema.NewDecoder()
+	if err := decoder.Decode(filter, r.URL.Query()); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	visitors, err := a.Database.GetLocationVisits(id, filter)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(visitors); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (a *App) getLocationStats(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, _ := vars["id"]
+
+	filter := new(LocationFilter)
+	decoder := schema.NewDecoder()
+	if err := decoder.Decode(filter, r.URL.Query()); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stats, err := a.Database.GetLocationStats(id, filter)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (a *App) getLocationTimeline(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, _ := vars["id"]
+
+	filter := new(TimelineFilter)
+	decoder := schema.NewDecoder()
+	if err := decoder.Decode(filter, r.URL.Query()); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	timeline, err := a.Database.GetLocationTimeline(id, filter)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(timeline); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (a *App) getRelatedLocations(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, _ := vars["id"]
+
+	filter := new(RecommendationFilter)
+	decoder := schema.NewDecoder()
+	if err := decoder.Decode(filter, r.URL.Query()); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	locations, err := a.Database.GetRelatedLocations(id, filter)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(locations); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (a *App) createLocation(w http.ResponseWriter, r *http.Request) {
+	location := new(Location)
+	if err := json.NewDecoder(r.Body).Decode(location); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := a.database(r).InsertLocation(location); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+	a.changes.publish()
+
+	w.Header().Set("Location", fmt.Sprintf("/locations/%d", *location.ID))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(createdJSON{location.ID}); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (a *App) bulkLocations(w http.ResponseWriter, r *http.Request) {
+	a.bulkInsert(w, r, func() interface{} { return new(Location) })
+}
+
+func (a *App) updateLocation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, _ := vars["id"]
+
+	location := new(Location)
+	if err := json.NewDecoder(r.Body).Decode(location); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	version, err := expectedVersion(r, location.Version)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	location.Version = version
+
+	if err := a.database(r).UpdateLocation(id, location); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+	a.changes.publish()
+
+	w.Header().Set("ETag", entityTag(*location.Version))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(emptyJSON); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (a *App) deleteLocation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, _ := vars["id"]
+
+	cascade := a.Config.DeletePolicy == deletePolicyCascade
+	writeDeleteResult(w, a.database(r).DeleteLocation(id, cascade))
+}
+
+func (a *App) getCountries(w http.ResponseWriter, r *http.Request) {
+	countries, err := a.Database.GetCountries()
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(countries); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (a *App) getCountryAverageMark(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	country, _ := vars["country"]
+
+	filter := new(LocationFilter)
+	decoder := schema.NewDecoder()
+	if err := decoder.Decode(filter, r.URL.Query()); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	avg, err := a.Database.GetCountryAverageMark(country, filter)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(avg); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (a *App) getCityAverageMark(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	city, _ := vars["city"]
+
+	filter := new(LocationFilter)
+	decoder := schema.NewDecoder()
+	if err := decoder.Decode(filter, r.URL.Query()); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	avg, err := a.Database.GetCityAverageMark(city, filter)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(avg); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (a *App) applyBatch(w http.ResponseWriter, r *http.Request) {
+	batch := new(Batch)
+	if err := json.NewDecoder(r.Body).Decode(batch); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := batch.Validate(); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	errs, err := a.database(r).ApplyBatch(batch)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	a.changes.publish()
+
+	results := &BatchResults{make([]*BatchResult, len(errs))}
+	for i, err := range errs {
+		results.Rows[i] = batchResult(err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(results); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (a *App) export(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entity, _ := vars["entity"]
+
+	query := r.URL.Query()
+	format := query.Get("format")
+	query.Del("format")
+
+	var row, filter interface{}
+	var export func(fn func(row interface{}) error) error
+	switch entity {
+	case usersTableName:
+		f := new(UserListFilter)
+		row, filter, export = new(User), f, func(fn func(row interface{}) error) error {
+			return a.Database.ExportUsers(f, fn)
+		}
+	case locationsTableName:
+		f := new(LocationListFilter)
+		row, filter, export = new(Location), f, func(fn func(row interface{}) error) error {
+			return a.Database.ExportLocations(f, fn)
+		}
+	case visitsTableName:
+		f := new(VisitListFilter)
+		row, filter, export = new(Visit), f, func(fn func(row interface{}) error) error {
+			return a.Database.ExportVisits(f, fn)
+		}
+	default:
+		http.Error(w, "unknown entity "+entity, http.StatusNotFound)
+		return
+	}
+
+	decoder := schema.NewDecoder()
+	if err := decoder.Decode(filter, query); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	out := &trackingWriter{Writer: w}
+	writer, contentType, err := newRowWriter(format, out, row)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The response is streamed, so errors after the response is started
+	// can only be logged and terminate the stream.
+	w.Header().Set("Content-Type", contentType)
+	err = export(writer.Write)
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err != nil {
+		log.Println(err)
+		if !out.written {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+}
+
+func (a *App) getVisits(w http.ResponseWriter, r *http.Request) {
+	filter := new(VisitListFilter)
+	decoder := schema.NewDecoder()
+	if err := decoder.Decode(filter, r.URL.Query()); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	visits, err := a.Database.GetVisits(filter)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(visits); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (a *App) getVisit(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, _ := vars["id"]
+
+	filter := new(AsOfFilter)
+	decoder := schema.NewDecoder()
+	decoder.IgnoreUnknownKeys(true)
+	if err := decoder.Decode(filter, r.URL.Query()); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	visit, err := a.Database.GetVisit(id, filter.AsOf)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if checkNotModified(w, r, visit.Version, visit.UpdatedAt) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(visit); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (a *App) createVisit(w http.ResponseWriter, r *http.Request) {
+	visit := new(Visit)
+	if err := json.NewDecoder(r.Body).Decode(visit); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := a.database(r).InsertVisit(visit); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+	a.changes.publish()
+
+	w.Header().Set("Location", fmt.Sprintf("/visits/%d", *visit.ID))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(createdJSON{visit.ID}); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (a *App) bulkVisits(w http.ResponseWriter, r *http.Request) {
+	a.bulkInsert(w, r, func() interface{} { return new(Visit) })
+}
+
+func (a *App) updateVisit(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, _ := vars["id"]
+
+	visit := new(Visit)
+	if err := json.NewDecoder(r.Body).Decode(visit); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	version, err := expectedVersion(r, visit.Version)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	visit.Version = version
+
+	if err := a.database(r).UpdateVisit(id, visit); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+	a.changes.publish()
+
+	w.Header().Set("ETag", entityTag(*visit.Version))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(emptyJSON); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (a *App) deleteVisit(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, _ := vars["id"]
+
+	writeDeleteResult(w, a.database(r).DeleteVisit(id))
+}
+
+// getChanges streams creations and updates of entities as server-sent events
+// starting after the sequence number from Last-Event-ID header.
+func (a *App) getChanges(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// Reconnecting clients resume after the last received change, new ones
+	// receive changes made after they subscribed.
+	var after uint64
+	var err error
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		if after, err = strconv.ParseUint(id, 10, 64); err != nil {
+			log.Println(err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else if after, err = a.Database.GetLastChange(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	notifications := a.changes.subscribe()
+	defer a.changes.unsubscribe(notifications)
+
+	keepAlive := time.NewTicker(changesKeepAlive * time.Second)
+	defer keepAlive.Stop()
+
+	w.Header().Set("Content-Type", contentTypeEventStream)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		changes, err := a.Database.GetChanges(after, changesPageSize)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		for _, change := range changes {
+			data, err := json.Marshal(change)
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", change.Seq, change.Operation, data); err != nil {
+				log.Println(err)
+				return
+			}
+			after = change.Seq
+		}
+		flusher.Flush()
+
+		if len(changes) == changesPageSize {
+			continue
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-notifications:
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				log.Println(err)
+				return
+			}
+		}
+	}
+}
+
+func (a *App) getWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := a.Database.GetWebhooks()
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(webhooks); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (a *App) createWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook := new(Webhook)
+	if err := json.NewDecoder(r.Body).Decode(webhook); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := webhook.Validate(); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := a.Database.InsertWebhook(webhook); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(createdJSON{webhook.ID}); err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (a *App) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, _ := vars["id"]
+
+	writeDeleteResult(w, a.Database.DeleteWebhook(id))
+}
+
+func (a *App) getOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(a.openAPI); err != nil {
+		log.Println(err)
+	}
+}
+
+func (a *App) getHistory(table string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, _ := vars["id"]
+
+		history, err := a.Database.GetHistory(table, id)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, err.Error(), errorStatus(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(history); err != nil {
+			log.Println(err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
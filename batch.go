@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Batch operation actions.
+const (
+	batchActionCreate = "create"
+	batchActionUpdate = "update"
+)
+
+// Batch contains list of operations applied in order within one transaction.
+// When Atomic is set, failure of any operation rolls back the whole batch.
+type Batch struct {
+	Atomic     bool              `json:"atomic"`
+	Operations []*BatchOperation `json:"operations"`
+}
+
+// Validate checks that batch contains no null operations.
+func (b *Batch) Validate() error {
+	for i, op := range b.Operations {
+		if op == nil {
+			return fmt.Errorf("operation %d is null", i)
+		}
+	}
+	return nil
+}
+
+// BatchOperation contains single create or update of user, location or visit.
+// Data holds entity in the same format as in the corresponding POST request.
+type BatchOperation struct {
+	Action string          `json:"action"`
+	Entity string          `json:"entity"`
+	ID     *uint32         `json:"id"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// BatchResult contains batch operation status.
+type BatchResult struct {
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BatchResults contains slice of batch operation statuses in order of operations.
+type BatchResults struct {
+	Rows []*BatchResult `json:"results"`
+}
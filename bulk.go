@@ -0,0 +1,82 @@
+package main
+
+const (
+	bulkChunkSize   = 1000
+	maxBulkLineSize = 1 << 20
+)
+
+// BulkError contains error of the bulk ingest line.
+type BulkError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// BulkResult contains numbers of accepted and rejected bulk ingest lines.
+type BulkResult struct {
+	Accepted int          `json:"accepted"`
+	Rejected int          `json:"rejected"`
+	Errors   []*BulkError `json:"errors"`
+}
+
+// reject records the line as rejected with specified error.
+func (b *BulkResult) reject(line int, err error) {
+	b.Rejected++
+	b.Errors = append(b.Errors, &BulkError{line, err.Error()})
+}
+
+// add records insertion errors of the specified lines.
+func (b *BulkResult) add(lines []int, errs []error) {
+	for i, err := range errs {
+		if err != nil {
+			b.reject(lines[i], err)
+			continue
+		}
+		b.Accepted++
+	}
+}
+
+// bulkInserter inserts rows in chunks of bulkChunkSize recording results
+// by line numbers and notifies change feed about inserted chunks.
+type bulkInserter struct {
+	database *Database
+	changes  *changeFeed
+	result   *BulkResult
+	rows     []interface{}
+	lines    []int
+}
+
+func newBulkInserter(d *Database, changes *changeFeed) *bulkInserter {
+	return &bulkInserter{
+		database: d,
+		changes:  changes,
+		result:   &BulkResult{Errors: []*BulkError{}},
+		rows:     make([]interface{}, 0, bulkChunkSize),
+		lines:    make([]int, 0, bulkChunkSize),
+	}
+}
+
+// insert queues the row inserting queued rows once the chunk is full.
+func (b *bulkInserter) insert(line int, row interface{}) error {
+	b.rows, b.lines = append(b.rows, row), append(b.lines, line)
+	if len(b.rows) < bulkChunkSize {
+		return nil
+	}
+	return b.flush()
+}
+
+// flush inserts queued rows.
+func (b *bulkInserter) flush() error {
+	if len(b.rows) == 0 {
+		return nil
+	}
+
+	errs, err := b.database.InsertRows(b.rows)
+	if err != nil {
+		return err
+	}
+
+	b.changes.publish()
+	b.result.add(b.lines, errs)
+	b.rows, b.lines = b.rows[:0], b.lines[:0]
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"sync"
+)
+
+const (
+	// changesPageSize is maximum number of changes read from database at once.
+	changesPageSize = 1000
+	// changesKeepAlive is interval in seconds between keep-alive comments
+	// sent to idle change feed subscribers.
+	changesKeepAlive = 15
+)
+
+// ChangeEvent contains created or updated entity fields streamed to
+// change feed subscribers.
+type ChangeEvent struct {
+	Seq       uint64 `json:"seq" db:"seq"`
+	Entity    string `json:"entity" db:"entity"`
+	ID        uint32 `json:"id" db:"entity_id"`
+	Operation string `json:"operation" db:"operation"`
+	Changed   JSONB  `json:"changed" db:"new"`
+}
+
+// changeFeed wakes up change feed subscribers when entities are changed.
+type changeFeed struct {
+	mu          sync.Mutex
+	subscribers map[chan struct{}]struct{}
+}
+
+func newChangeFeed() *changeFeed {
+	return &changeFeed{subscribers: make(map[chan struct{}]struct{})}
+}
+
+// subscribe returns channel receiving a value after changes are published.
+func (f *changeFeed) subscribe() chan struct{} {
+	c := make(chan struct{}, 1)
+
+	f.mu.Lock()
+	f.subscribers[c] = struct{}{}
+	f.mu.Unlock()
+
+	return c
+}
+
+func (f *changeFeed) unsubscribe(c chan struct{}) {
+	f.mu.Lock()
+	delete(f.subscribers, c)
+	f.mu.Unlock()
+}
+
+// publish notifies subscribers about committed changes. Subscribers that
+// have not yet handled previous notification are notified only once.
+func (f *changeFeed) publish() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for c := range f.subscribers {
+		select {
+		case c <- struct{}{}:
+		default:
+		}
+	}
+}
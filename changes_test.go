@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// TestChangesCommitOrder checks that change of a transaction committed while
+// another one is in progress does not overtake changes of the latter.
+func TestChangesCommitOrder(t *testing.T) {
+	d := testDatabase(t)
+	after, err := d.GetLastChange()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := d.begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Rollback()
+	if err := d.insertUser(first, testUser("first@example.com")); err != nil {
+		t.Fatal(err)
+	}
+
+	second := make(chan error, 1)
+	go func() {
+		second <- d.InsertUser(testUser("second@example.com"))
+	}()
+
+	select {
+	case err := <-second:
+		t.Fatalf("second transaction finished while the first one is in progress: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	changes, err := d.GetChanges(after, changesPageSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("got %d changes while the first transaction is in progress, want none", len(changes))
+	}
+
+	if err := first.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-second; err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err = d.GetChanges(after, changesPageSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("got %d changes, want 2", len(changes))
+	}
+	for i, email := range []string{"first@example.com", "second@example.com"} {
+		if !bytes.Contains(changes[i].Changed, []byte(email)) {
+			t.Errorf("change %d is %s, want change of %s", i, changes[i].Changed, email)
+		}
+	}
+}
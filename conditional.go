@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// entityTag returns strong entity tag of the entity version.
+func entityTag(version uint64) string {
+	return `"` + strconv.FormatUint(version, 10) + `"`
+}
+
+// matchTag reports whether the If-None-Match or If-Match header value lists the tag.
+func matchTag(header string, tag string) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNotModified sets validators of the entity version and responds with
+// 304 Not Modified when the client's copy is up to date.
+// It reports whether the response has been written.
+func checkNotModified(w http.ResponseWriter, r *http.Request, version *uint64, updatedAt *time.Time) bool {
+	if version == nil || updatedAt == nil {
+		return false
+	}
+
+	tag := entityTag(*version)
+	w.Header().Set("ETag", tag)
+	w.Header().Set("Last-Modified", updatedAt.UTC().Format(http.TimeFormat))
+
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		if !matchTag(header, tag) {
+			return false
+		}
+	} else {
+		since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+		if err != nil || updatedAt.Truncate(time.Second).After(since) {
+			return false
+		}
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// expectedVersion returns entity version required by the If-Match header
+// or by the version from the request body.
+func expectedVersion(r *http.Request, body *uint64) (*uint64, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return body, nil
+	}
+
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return nil, fmt.Errorf("invalid If-Match header %q", header)
+	}
+	version, err := strconv.ParseUint(header[1:len(header)-1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid If-Match header %q", header)
+	}
+
+	if body != nil && *body != version {
+		return nil, errors.New("If-Match header and body versions differ")
+	}
+	return &version, nil
+}
@@ -9,12 +9,30 @@ import (
 
 // Config contains server properties.
 type Config struct {
-	Host     string    `json:"host"`
-	Port     string    `json:"port"`
-	DBConfig *DBConfig `json:"db"`
-	Data     string    `json:"data"`
+	Host                string     `json:"host"`
+	Port                string     `json:"port"`
+	GRPCPort            string     `json:"grpc_port"`
+	DBConfig            *DBConfig  `json:"db"`
+	Data                string     `json:"data"`
+	DeletePolicy        string     `json:"delete_policy"`
+	IdempotencyTTL      int        `json:"idempotency_ttl"`
+	IdempotencyCapacity int        `json:"idempotency_capacity"`
+	Webhooks            []*Webhook `json:"webhooks"`
+	AdminToken          string     `json:"admin_token"`
 }
 
+// Idempotency keys defaults: outcomes are kept for a day (TTL is in seconds).
+const (
+	defaultIdempotencyTTL      = 24 * 60 * 60
+	defaultIdempotencyCapacity = 10000
+)
+
+// Delete policies define how deletion of users and locations treats their visits.
+const (
+	deletePolicyReject  = "reject"
+	deletePolicyCascade = "cascade"
+)
+
 // DBConfig contains server database properties.
 type DBConfig struct {
 	Driver   string `json:"driver"`
@@ -42,6 +60,27 @@ func ConfigurationLoad(file string) (*Config, error) {
 		return nil, err
 	}
 
+	switch config.DeletePolicy {
+	case "":
+		config.DeletePolicy = deletePolicyReject
+	case deletePolicyReject, deletePolicyCascade:
+	default:
+		return nil, fmt.Errorf("unknown delete policy %q", config.DeletePolicy)
+	}
+
+	if config.IdempotencyTTL <= 0 {
+		config.IdempotencyTTL = defaultIdempotencyTTL
+	}
+	if config.IdempotencyCapacity <= 0 {
+		config.IdempotencyCapacity = defaultIdempotencyCapacity
+	}
+
+	for _, webhook := range config.Webhooks {
+		if err := webhook.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
 	return config, nil
 }
 
@@ -55,3 +94,11 @@ func (d *DBConfig) GetDSN() string {
 func (c *Config) GetAddr() string {
 	return net.JoinHostPort(c.Host, c.Port)
 }
+
+// GetGRPCAddr returns gRPC server address, empty when gRPC port is not set.
+func (c *Config) GetGRPCAddr() string {
+	if c.GRPCPort == "" {
+		return ""
+	}
+	return net.JoinHostPort(c.Host, c.GRPCPort)
+}
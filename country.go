@@ -0,0 +1,13 @@
+package main
+
+// Country contains country summary over its locations.
+type Country struct {
+	Country   string `json:"country" db:"country"`
+	Locations int64  `json:"locations" db:"locations"`
+	Visits    int64  `json:"visits" db:"visits"`
+}
+
+// Countries contains slice of countries.
+type Countries struct {
+	Rows []*Country `json:"countries"`
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// csvColumns returns CSV column names of the entity type fields
+// serialized to JSON along with the fields indexes.
+func csvColumns(t reflect.Type) ([]string, []int) {
+	var names []string
+	var indexes []int
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		names, indexes = append(names, name), append(indexes, i)
+	}
+	return names, indexes
+}
+
+// csvHeader returns CSV column names of the entity fields.
+func csvHeader(row interface{}) []string {
+	header, _ := csvColumns(reflect.TypeOf(row).Elem())
+	return header
+}
+
+// csvRecord returns CSV values of the entity fields, nil fields are empty.
+func csvRecord(row interface{}) []string {
+	v := reflect.ValueOf(row).Elem()
+	_, indexes := csvColumns(v.Type())
+	record := make([]string, len(indexes))
+	for i, index := range indexes {
+		if field := v.Field(index); !field.IsNil() {
+			record[i] = fmt.Sprint(field.Elem().Interface())
+		}
+	}
+	return record
+}
+
+// csvFields returns indexes of the entity fields corresponding to CSV header columns.
+func csvFields(row interface{}, header []string) ([]int, error) {
+	names, indexes := csvColumns(reflect.TypeOf(row).Elem())
+	columns := make(map[string]int, len(names))
+	for i, name := range names {
+		columns[name] = indexes[i]
+	}
+
+	fields := make([]int, len(header))
+	for i, column := range header {
+		field, ok := columns[strings.TrimSpace(column)]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q", column)
+		}
+		fields[i] = field
+	}
+	return fields, nil
+}
+
+// setCSVRecord sets the entity fields specified by indexes from CSV values,
+// empty values leave fields nil.
+func setCSVRecord(row interface{}, fields []int, record []string) error {
+	v := reflect.ValueOf(row).Elem()
+	for i, value := range record {
+		if value == "" {
+			continue
+		}
+
+		field := v.Field(fields[i])
+		elem := reflect.New(field.Type().Elem())
+		switch kind := elem.Elem().Kind(); kind {
+		case reflect.String:
+			elem.Elem().SetString(value)
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, err := strconv.ParseInt(value, 10, elem.Elem().Type().Bits())
+			if err != nil {
+				return fmt.Errorf("column %d: %v", i+1, err)
+			}
+			elem.Elem().SetInt(n)
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n, err := strconv.ParseUint(value, 10, elem.Elem().Type().Bits())
+			if err != nil {
+				return fmt.Errorf("column %d: %v", i+1, err)
+			}
+			elem.Elem().SetUint(n)
+		default:
+			return fmt.Errorf("column %d: unsupported kind %s", i+1, kind)
+		}
+		field.Set(elem)
+	}
+	return nil
+}
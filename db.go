@@ -1,14 +1,17 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	// postgres driver
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
@@ -18,6 +21,8 @@ import (
 type Database struct {
 	Socket           *sqlx.DB
 	StatementBuilder sq.StatementBuilderType
+
+	requestID string
 }
 
 const (
@@ -26,6 +31,37 @@ const (
 	usersTableName     = "users"
 	locationsTableName = "locations"
 	visitsTableName    = "visits"
+	coVisitsTableName  = "co_visits"
+	historyTableName   = "history"
+	webhooksTableName  = "webhooks"
+
+	webhookDeliveriesTableName = "webhook_deliveries"
+	locationMarksTableName     = "location_marks"
+)
+
+// locationDocument is a lowercased word vector over location's place, city and country.
+// It must match locations_search_idx expression in schema to be served by the index.
+const locationDocument = "to_tsvector('simple', place || ' ' || city || ' ' || country)"
+
+// markHistogram selects numbers of visits with marks from 0 to 5.
+const markHistogram = `ARRAY[
+	count(*) FILTER (WHERE visits.mark = 0),
+	count(*) FILTER (WHERE visits.mark = 1),
+	count(*) FILTER (WHERE visits.mark = 2),
+	count(*) FILTER (WHERE visits.mark = 3),
+	count(*) FILTER (WHERE visits.mark = 4),
+	count(*) FILTER (WHERE visits.mark = 5)
+] AS histogram`
+
+var (
+	// ErrNotFound is returned when the requested row does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when the operation conflicts with existing rows.
+	ErrConflict = errors.New("conflict")
+	// ErrDuplicate is returned when the row violates a unique constraint.
+	ErrDuplicate = errors.New("already exists")
+	// ErrRolledBack is returned for batch operations rolled back due to failure of another one.
+	ErrRolledBack = errors.New("rolled back")
 )
 
 var (
@@ -76,8 +112,63 @@ func (d *Database) createSchema(file string) error {
 	return nil
 }
 
-func (d *Database) getByID(table string, id string, dest interface{}) error {
-	sql, args, err := d.StatementBuilder.Select("*").From(table).Where(sq.Eq{"id": id}).ToSql()
+// Request returns database attributing changes made through it to the request id.
+func (d *Database) Request(id string) *Database {
+	request := *d
+	request.requestID = id
+	return &request
+}
+
+// begin starts transaction attributing changes to the request id, if any.
+func (d *Database) begin() (*sqlx.Tx, error) {
+	tx, err := d.Socket.Beginx()
+	if err != nil {
+		return nil, err
+	}
+
+	if d.requestID != "" {
+		if _, err := tx.Exec("SELECT set_config('app.request_id', $1, true)", d.requestID); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	return tx, nil
+}
+
+// write runs fn inside a transaction.
+func (d *Database) write(fn func(e sqlx.Ext) error) error {
+	tx, err := d.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// getByID gets row of the table specified by id, as it was at asOf unix time when specified.
+func (d *Database) getByID(name string, id string, asOf *int64, dest interface{}) error {
+	from := name
+	if asOf != nil {
+		entityID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		from = entityTable(name, entityID, *asOf)
+	}
+	return d.get(from, "id", id, dest)
+}
+
+func (d *Database) getBy(name string, column string, value string, asOf *int64, dest interface{}) error {
+	return d.get(table(name, asOf), column, value, dest)
+}
+
+func (d *Database) get(from string, column string, value string, dest interface{}) error {
+	sql, args, err := d.StatementBuilder.Select("*").From(from).Where(sq.Eq{column: value}).ToSql()
 	if err != nil {
 		log.Println(err)
 		return err
@@ -91,19 +182,296 @@ func (d *Database) getByID(table string, id string, dest interface{}) error {
 	return nil
 }
 
-// GetUser returns user specified by id from database.
-func (d *Database) GetUser(id string) (*User, error) {
+func (d *Database) deleteByID(e sqlx.Execer, table string, id string) error {
+	sql, args, err := d.StatementBuilder.Delete(table).Where(sq.Eq{"id": id}).ToSql()
+	if err != nil {
+		return err
+	}
+
+	result, err := e.Exec(sql, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// deleteWithVisits deletes row specified by id from table.
+// Visits referencing the row by column are deleted when cascade is set,
+// otherwise their presence makes the deletion fail with ErrConflict.
+func (d *Database) deleteWithVisits(table string, column string, id string, cascade bool) error {
+	tx, err := d.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if cascade {
+		sql, args, err := d.StatementBuilder.Delete(visitsTableName).Where(sq.Eq{column: id}).ToSql()
+		if err != nil {
+			return err
+		}
+		if _, err = tx.Exec(sql, args...); err != nil {
+			return err
+		}
+	} else {
+		sql, args, err := d.StatementBuilder.
+			Select("count(*)").
+			From(visitsTableName).
+			Where(sq.Eq{column: id}).
+			ToSql()
+		if err != nil {
+			return err
+		}
+
+		var count int
+		if err = tx.Get(&count, sql, args...); err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrConflict
+		}
+	}
+
+	// Visits may still be inserted concurrently after the check.
+	if err = d.deleteByID(tx, table, id); err != nil {
+		if err, ok := err.(*pq.Error); ok && err.Code == foreignKeyViolation {
+			return ErrConflict
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (d *Database) paginate(query sq.SelectBuilder, page *Page) sq.SelectBuilder {
+	if page.After != nil {
+		query = query.Where(sq.Gt{"id": page.After})
+	}
+	return query.OrderBy("id").Limit(page.GetLimit())
+}
+
+// updateVersioned executes update of the row specified by id bumping its version
+// and returns the new version. When expected version is set, the row is updated
+// only if its version matches, otherwise ErrConflict is returned. ErrNotFound
+// is returned when the row does not exist.
+func (d *Database) updateVersioned(e sqlx.Queryer, table string, update sq.UpdateBuilder, id string, expected *uint64) (*uint64, error) {
+	update = update.
+		Set("version", sq.Expr("version + 1")).
+		Set("updated_at", sq.Expr("now()")).
+		Where(sq.Eq{"id": id})
+	if expected != nil {
+		update = update.Where(sq.Eq{"version": expected})
+	}
+
+	sql, args, err := update.Suffix("RETURNING version").ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := e.Queryx(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		if expected == nil {
+			return nil, ErrNotFound
+		}
+		rows.Close()
+		return nil, d.conflict(e, table, id)
+	}
+
+	version := new(uint64)
+	if err := rows.Scan(version); err != nil {
+		return nil, err
+	}
+	return version, rows.Err()
+}
+
+// conflict returns ErrConflict when the row specified by id exists in table
+// and ErrNotFound otherwise.
+func (d *Database) conflict(e sqlx.Queryer, table string, id string) error {
+	sql, args, err := d.StatementBuilder.Select("1").From(table).Where(sq.Eq{"id": id}).Prefix("SELECT EXISTS (").Suffix(")").ToSql()
+	if err != nil {
+		return err
+	}
+
+	var exists bool
+	if err := sqlx.Get(e, &exists, sql, args...); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return ErrConflict
+}
+
+// Codes of PostgreSQL integrity constraint violation errors.
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
+// insertRow inserts row with specified values of table columns and returns its id.
+// Columns must start with id, which is assigned from the table sequence when
+// it is nil. Sequences are moved past the specified ids by syncSequences.
+func (d *Database) insertRow(e sqlx.Ext, table string, columns []string, id *uint32, values ...interface{}) (*uint32, error) {
+	assigned := id == nil
+	insert := d.StatementBuilder.Insert(table)
+	if assigned {
+		insert = insert.Columns(columns[1:]...).Values(values...).Suffix("RETURNING id")
+	} else {
+		insert = insert.Columns(columns...).Values(append([]interface{}{id}, values...)...)
+	}
+
+	sql, args, err := insert.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if assigned {
+		id = new(uint32)
+		err = sqlx.Get(e, id, sql, args...)
+	} else {
+		_, err = e.Exec(sql, args...)
+	}
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// syncSequences moves id sequences of the tables past the ids specified by
+// clients, so that ids assigned later do not collide with them. Changes of
+// entities are serialized by history_lock (see schema) from their first
+// statement until commit, so concurrent transactions assign ids only after
+// the sync is committed.
+func (d *Database) syncSequences(e sqlx.Execer, tables ...string) error {
+	for _, table := range tables {
+		sync := fmt.Sprintf(
+			"SELECT setval('%[1]s_id_seq', GREATEST("+
+				"CASE WHEN is_called THEN last_value + 1 ELSE last_value END, "+
+				"(SELECT COALESCE(max(id), 0) + 1 FROM %[1]s)), false) FROM %[1]s_id_seq",
+			table)
+		if _, err := e.Exec(sync); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetUser returns user specified by id from database, as it was at asOf
+// unix time when specified.
+func (d *Database) GetUser(id string, asOf *int64) (*User, error) {
 	user := new(User)
-	err := d.getByID(usersTableName, id, user)
+	err := d.getByID(usersTableName, id, asOf, user)
 	return user, err
 }
 
-// GetUserVisits returns user's visits specified by user id from database.
-func (d *Database) GetUserVisits(id string, filter *PlaceFilter) (*Places, error) {
+// GetUserByEmail returns user specified by email from database, as it was
+// at asOf unix time when specified.
+func (d *Database) GetUserByEmail(email string, asOf *int64) (*User, error) {
+	user := new(User)
+	err := d.getBy(usersTableName, "email", email, asOf, user)
+	return user, err
+}
+
+// likeEscaper escapes LIKE pattern special characters.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// SearchUsers returns page of users whose first or last name starts with
+// the specified name regardless of case.
+func (d *Database) SearchUsers(filter *UserSearchFilter) (*Users, error) {
+	if filter.Name == nil || *filter.Name == "" {
+		return nil, errors.New("empty search name")
+	}
+
+	prefix := likeEscaper.Replace(strings.ToLower(*filter.Name)) + "%"
+	users := d.StatementBuilder.
+		Select(usersTableColumns...).
+		From(usersTableName).
+		Where(sq.Or{
+			sq.Expr("lower(first_name) LIKE ?", prefix),
+			sq.Expr("lower(last_name) LIKE ?", prefix),
+		})
+
+	sql, args, err := d.paginate(users, &filter.Page).ToSql()
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	result := &Users{Rows: []*User{}}
+	if err := d.Socket.Select(&result.Rows, sql, args...); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	if n := len(result.Rows); n > 0 {
+		result.Next = filter.nextCursor(n, result.Rows[n-1].ID)
+	}
+	return result, nil
+}
+
+// usersQuery returns query selecting users matching specified filter.
+func (d *Database) usersQuery(filter *UserListFilter) sq.SelectBuilder {
+	users := d.StatementBuilder.Select(usersTableColumns...).From(usersTableName)
+
+	if filter.Gender != nil {
+		users = users.Where(sq.Eq{"gender": filter.Gender})
+	}
+	if filter.FromBirthDate != nil {
+		users = users.Where(sq.Gt{"birth_date": filter.FromBirthDate})
+	}
+	if filter.ToBirthDate != nil {
+		users = users.Where(sq.Lt{"birth_date": filter.ToBirthDate})
+	}
+
+	return users
+}
+
+// GetUsers returns page of users matching specified filter from database.
+func (d *Database) GetUsers(filter *UserListFilter) (*Users, error) {
+	sql, args, err := d.paginate(d.usersQuery(filter), &filter.Page).ToSql()
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	result := &Users{Rows: []*User{}}
+	if err := d.Socket.Select(&result.Rows, sql, args...); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	if n := len(result.Rows); n > 0 {
+		result.Next = filter.nextCursor(n, result.Rows[n-1].ID)
+	}
+	return result, nil
+}
+
+// userPlaces returns query selecting columns of user's visits joined with locations,
+// as they were at asOf unix time when specified.
+func (d *Database) userPlaces(id string, filter *PlaceFilter, asOf *int64, columns ...string) sq.SelectBuilder {
 	places := d.StatementBuilder.
-		Select("mark", "visited_at", "place").
-		From(visitsTableName).
-		Join(fmt.Sprintf("%s ON %s.location = %s.id", locationsTableName, visitsTableName, locationsTableName)).
+		Select(columns...).
+		From(table(visitsTableName, asOf)).
+		Join(fmt.Sprintf("%s ON %s.location = %s.id", table(locationsTableName, asOf), visitsTableName, locationsTableName)).
 		Where(sq.Eq{`"user"`: id})
 
 	if filter.FromDate != nil {
@@ -120,6 +488,13 @@ func (d *Database) GetUserVisits(id string, filter *PlaceFilter) (*Places, error
 		places = places.Where(sq.Lt{"distance": filter.Distance})
 	}
 
+	return places
+}
+
+// GetUserVisits returns user's visits specified by user id from database.
+func (d *Database) GetUserVisits(id string, filter *UserVisitsFilter) (*Places, error) {
+	places := d.userPlaces(id, &filter.PlaceFilter, filter.AsOf, "mark", "visited_at", "place")
+
 	sql, args, err := places.ToSql()
 	if err != nil {
 		log.Println(err)
@@ -135,33 +510,83 @@ func (d *Database) GetUserVisits(id string, filter *PlaceFilter) (*Places, error
 	return result, nil
 }
 
-// InsertUser inserts specified user into database.
-func (d *Database) InsertUser(user *User) error {
-	sql, args, err := d.StatementBuilder.
-		Insert(usersTableName).
-		Columns(usersTableColumns...).
-		Values(user.ID, user.Email, user.FirstName, user.LastName, user.Gender, user.BirthDate).
-		ToSql()
+// GetUserAverageMark returns average mark given by user specified by id.
+func (d *Database) GetUserAverageMark(id string, filter *PlaceFilter) (*UserAvgMark, error) {
+	places := d.userPlaces(id, filter, nil, `COALESCE("round"("avg"(visits.mark), 2), 0) AS "avg"`)
+
+	sql, args, err := places.ToSql()
 	if err != nil {
-		return err
+		log.Println(err)
+		return nil, err
 	}
 
-	_, err = d.Socket.Exec(sql, args...)
+	average := &UserAvgMark{}
+	if err = d.Socket.Get(average, sql, args...); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return average, nil
+}
+
+// GetUserStats returns statistics of marks given by user specified by id.
+func (d *Database) GetUserStats(id string, filter *PlaceFilter) (*UserStats, error) {
+	places := d.userPlaces(id, filter, nil,
+		`count(*) AS "count"`,
+		`COALESCE("round"("avg"(visits.mark), 2), 0) AS "avg"`,
+		markHistogram,
+		"count(DISTINCT locations.country) AS countries",
+	)
+
+	sql, args, err := places.ToSql()
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	stats := &UserStats{}
+	if err = d.Socket.Get(stats, sql, args...); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// InsertUser inserts specified user into database.
+func (d *Database) InsertUser(user *User) error {
+	return d.write(func(e sqlx.Ext) error {
+		specified := user.ID != nil
+		if err := d.insertUser(e, user); err != nil || !specified {
+			return err
+		}
+		return d.syncSequences(e, usersTableName)
+	})
+}
+
+func (d *Database) insertUser(e sqlx.Ext, user *User) error {
+	id, err := d.insertRow(e, usersTableName, usersTableColumns, user.ID, user.Email, user.FirstName, user.LastName, user.Gender, user.BirthDate)
+	user.ID = id
 	return err
 }
 
 // PopulateUsers inserts specified list of users into database.
 func (d *Database) PopulateUsers(users *Users) error {
-	for _, user := range users.Rows {
-		if err := d.InsertUser(user); err != nil {
-			return err
-		}
+	rows := make([]interface{}, len(users.Rows))
+	for i, user := range users.Rows {
+		rows[i] = user
 	}
-	return nil
+	return d.populate(rows)
 }
 
 // UpdateUser updates specified user's row in database.
 func (d *Database) UpdateUser(id string, user *User) error {
+	return d.write(func(e sqlx.Ext) error {
+		return d.updateUser(e, id, user)
+	})
+}
+
+func (d *Database) updateUser(e sqlx.Queryer, id string, user *User) error {
 	update := d.StatementBuilder.Update(usersTableName)
 
 	if user.Email != nil {
@@ -180,95 +605,463 @@ func (d *Database) UpdateUser(id string, user *User) error {
 		update = update.Set("birth_date", user.BirthDate)
 	}
 
-	update = update.Where(sq.Eq{"id": id})
-
-	sql, args, err := update.ToSql()
-	if err != nil {
-		return err
-	}
-
-	_, err = d.Socket.Exec(sql, args...)
+	version, err := d.updateVersioned(e, usersTableName, update, id, user.Version)
+	user.Version = version
 	return err
 }
 
-// GetLocation returns location specified by id from database.
-func (d *Database) GetLocation(id string) (*Location, error) {
+// DeleteUser deletes user specified by id from database.
+// User's visits are deleted too when cascade is set.
+func (d *Database) DeleteUser(id string, cascade bool) error {
+	return d.deleteWithVisits(usersTableName, `"user"`, id, cascade)
+}
+
+// GetLocation returns location specified by id from database, as it was at asOf
+// unix time when specified.
+func (d *Database) GetLocation(id string, asOf *int64) (*Location, error) {
 	location := new(Location)
-	err := d.getByID(locationsTableName, id, location)
+	err := d.getByID(locationsTableName, id, asOf, location)
 	return location, err
 }
 
-// GetLocationAverageMark returns average mark for location specified by id.
-func (d *Database) GetLocationAverageMark(id string, filter *LocationFilter) (*LocationAvgMark, error) {
-	const age = "date_part('year', age(to_timestamp(users.birth_date)))"
-
-	locations := d.StatementBuilder.
-		Select(`COALESCE("round"("avg"(visits.mark), 2), 0) AS "avg"`).
-		From(locationsTableName).
-		Join(fmt.Sprintf("%s ON %s.id = %s.location", visitsTableName, locationsTableName, visitsTableName)).
-		Join(fmt.Sprintf(`%s ON %s."user" = %s.id`, usersTableName, visitsTableName, usersTableName)).
-		Where(sq.Eq{locationsTableName + ".id": id})
+// locationsQuery returns query selecting locations matching specified filter.
+func (d *Database) locationsQuery(filter *LocationListFilter) sq.SelectBuilder {
+	locations := d.StatementBuilder.Select(locationsTableColumns...).From(locationsTableName)
 
-	if filter.FromDate != nil {
-		locations = locations.Where(sq.Gt{"visits.visited_at": filter.FromDate})
+	if filter.Country != nil {
+		locations = locations.Where(sq.Eq{"country": filter.Country})
 	}
-	if filter.ToDate != nil {
-		locations = locations.Where(sq.Lt{"visits.visited_at": filter.ToDate})
+	if filter.City != nil {
+		locations = locations.Where(sq.Eq{"city": filter.City})
 	}
-
-	if filter.FromAge != nil {
-		locations = locations.Where(sq.Gt{age: filter.FromAge})
+	if filter.FromDistance != nil {
+		locations = locations.Where(sq.Gt{"distance": filter.FromDistance})
 	}
-	if filter.ToAge != nil {
-		locations = locations.Where(sq.Lt{age: filter.ToAge})
+	if filter.ToDistance != nil {
+		locations = locations.Where(sq.Lt{"distance": filter.ToDistance})
 	}
 
-	if filter.Gender != nil {
-		locations = locations.Where(sq.Eq{"users.gender": filter.Gender})
-	}
+	return locations
+}
 
-	sql, args, err := locations.ToSql()
+// GetLocations returns page of locations matching specified filter from database.
+func (d *Database) GetLocations(filter *LocationListFilter) (*Locations, error) {
+	sql, args, err := d.paginate(d.locationsQuery(filter), &filter.Page).ToSql()
 	if err != nil {
 		log.Println(err)
 		return nil, err
 	}
 
-	average := &LocationAvgMark{}
-	if err = d.Socket.Get(average, sql, args...); err != nil {
+	result := &Locations{Rows: []*Location{}}
+	if err := d.Socket.Select(&result.Rows, sql, args...); err != nil {
 		log.Println(err)
 		return nil, err
 	}
 
-	return average, err
+	if n := len(result.Rows); n > 0 {
+		result.Next = filter.nextCursor(n, result.Rows[n-1].ID)
+	}
+	return result, nil
 }
 
-// InsertLocation inserts specified location into database.
-func (d *Database) InsertLocation(location *Location) error {
-	sql, args, err := d.StatementBuilder.
-		Insert(locationsTableName).
-		Columns(locationsTableColumns...).
-		Values(location.ID, location.Place, location.Country, location.City, location.Distance).
-		ToSql()
-	if err != nil {
-		return err
+// table returns source of table rows. When asOf unix time is set, the source
+// is the table state at that moment restored from the history.
+func table(name string, asOf *int64) string {
+	if asOf == nil {
+		return name
 	}
+	return restoredTable(name, nil, *asOf)
+}
 
-	_, err = d.Socket.Exec(sql, args...)
-	return err
+// entityTable returns source of the single row of the table specified by id
+// restored from the history as it was at asOf unix time. Unlike table, it
+// reads only changes of that entity.
+func entityTable(name string, id uint64, asOf int64) string {
+	return restoredTable(name, &id, asOf)
 }
 
-// PopulateLocations inserts specified list of locations into database.
-func (d *Database) PopulateLocations(locations *Locations) error {
-	for _, location := range locations.Rows {
-		if err := d.InsertLocation(location); err != nil {
-			return err
+// restoredTable returns source of table rows restored from the history as
+// they were at asOf unix time, restricted to the row specified by id when set.
+// Rows loaded on startup and not changed since have no history and are taken
+// from the table as they are.
+func restoredTable(name string, id *uint64, asOf int64) string {
+	changes, loaded := "", ""
+	if id != nil {
+		changes = fmt.Sprintf("AND entity_id = %d ", *id)
+		loaded = fmt.Sprintf("AND id = %d ", *id)
+	}
+	return fmt.Sprintf(
+		"(SELECT (jsonb_populate_record(NULL::%[1]s, state)).* FROM ("+
+			"SELECT DISTINCT ON (entity_id) state FROM %[2]s "+
+			"WHERE entity = '%[1]s' %[3]sAND changed_at <= to_timestamp(%[5]d) "+
+			"ORDER BY entity_id, seq DESC"+
+			") AS latest WHERE state IS NOT NULL "+
+			"UNION ALL SELECT * FROM %[1]s WHERE updated_at <= to_timestamp(%[5]d) %[4]s"+
+			"AND NOT EXISTS (SELECT 1 FROM %[2]s WHERE entity = '%[1]s' AND entity_id = %[1]s.id)"+
+			") AS %[1]s",
+		name, historyTableName, changes, loaded, asOf)
+}
+
+// userAge returns expression of user age in years at asOf unix time when
+// specified, otherwise at the current time.
+func userAge(asOf *int64) string {
+	if asOf == nil {
+		return "date_part('year', age(to_timestamp(users.birth_date)))"
+	}
+	return fmt.Sprintf("date_part('year', age(to_timestamp(%d), to_timestamp(users.birth_date)))", *asOf)
+}
+
+// filterVisitors restricts query joining visits with users by specified filter,
+// ages of users are taken at asOf unix time when specified.
+func filterVisitors(query sq.SelectBuilder, filter *LocationFilter, asOf *int64) sq.SelectBuilder {
+	if filter.FromDate != nil {
+		query = query.Where(sq.Gt{"visits.visited_at": filter.FromDate})
+	}
+	if filter.ToDate != nil {
+		query = query.Where(sq.Lt{"visits.visited_at": filter.ToDate})
+	}
+
+	if filter.FromAge != nil {
+		query = query.Where(sq.Gt{userAge(asOf): filter.FromAge})
+	}
+	if filter.ToAge != nil {
+		query = query.Where(sq.Lt{userAge(asOf): filter.ToAge})
+	}
+
+	if filter.Gender != nil {
+		query = query.Where(sq.Eq{"users.gender": filter.Gender})
+	}
+
+	return query
+}
+
+// averageMark returns average mark over visits of locations matching where condition,
+// as it was at asOf unix time when specified.
+func (d *Database) averageMark(where sq.Eq, filter *LocationFilter, asOf *int64) (*LocationAvgMark, error) {
+	locations := d.StatementBuilder.
+		Select(`COALESCE("round"("avg"(visits.mark), 2), 0) AS "avg"`).
+		From(table(locationsTableName, asOf)).
+		Join(fmt.Sprintf("%s ON %s.id = %s.location", table(visitsTableName, asOf), locationsTableName, visitsTableName)).
+		Join(fmt.Sprintf(`%s ON %s."user" = %s.id`, table(usersTableName, asOf), visitsTableName, usersTableName)).
+		Where(where)
+	locations = filterVisitors(locations, filter, asOf)
+
+	sql, args, err := locations.ToSql()
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	average := &LocationAvgMark{}
+	if err = d.Socket.Get(average, sql, args...); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return average, err
+}
+
+// GetLocationAverageMark returns average mark for location specified by id.
+func (d *Database) GetLocationAverageMark(id string, filter *LocationAvgFilter) (*LocationAvgMark, error) {
+	return d.averageMark(sq.Eq{locationsTableName + ".id": id}, &filter.LocationFilter, filter.AsOf)
+}
+
+// GetCountryAverageMark returns average mark for locations in specified country.
+func (d *Database) GetCountryAverageMark(country string, filter *LocationFilter) (*LocationAvgMark, error) {
+	return d.averageMark(sq.Eq{locationsTableName + ".country": country}, filter, nil)
+}
+
+// GetCityAverageMark returns average mark for locations in specified city.
+func (d *Database) GetCityAverageMark(city string, filter *LocationFilter) (*LocationAvgMark, error) {
+	return d.averageMark(sq.Eq{locationsTableName + ".city": city}, filter, nil)
+}
+
+// SearchLocations returns locations whose place, city or country match
+// all words of the query, ordered by relevance.
+func (d *Database) SearchLocations(filter *LocationSearchFilter) (*Locations, error) {
+	if filter.Query == nil || strings.TrimSpace(*filter.Query) == "" {
+		return nil, errors.New("empty search query")
+	}
+
+	sql, args, err := d.StatementBuilder.
+		Select(locationsTableColumns...).
+		From(locationsTableName).
+		JoinClause("CROSS JOIN plainto_tsquery('simple', ?) AS query", *filter.Query).
+		Where(locationDocument+" @@ query").
+		OrderBy("ts_rank("+locationDocument+", query) DESC", "id").
+		Limit(filter.GetLimit()).
+		ToSql()
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	result := &Locations{Rows: []*Location{}}
+	if err := d.Socket.Select(&result.Rows, sql, args...); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetTopLocations returns locations ranked by average mark, locations without
+// marks are not ranked. Ties are broken by number of visits and then by location id.
+// Current ranking of all visitors is read from location_marks, rankings
+// of filtered visitors or at the past time are aggregated from visits.
+func (d *Database) GetTopLocations(filter *LocationTopFilter) (*LocationRanks, error) {
+	if filter.AsOf != nil || filter.FromDate != nil || filter.ToDate != nil ||
+		filter.FromAge != nil || filter.ToAge != nil || filter.Gender != nil {
+		return d.aggregateTopLocations(filter)
+	}
+
+	locations := d.StatementBuilder.
+		Select(
+			"locations.id",
+			"place",
+			"country",
+			"city",
+			`avg_mark AS "avg"`,
+			"visits",
+		).
+		From(locationMarksTableName).
+		Join(fmt.Sprintf("%s ON %s.id = %s.location", locationsTableName, locationsTableName, locationMarksTableName)).
+		Where("marked > 0")
+
+	if filter.Country != nil {
+		locations = locations.Where(sq.Eq{"country": filter.Country})
+	}
+	if filter.City != nil {
+		locations = locations.Where(sq.Eq{"city": filter.City})
+	}
+	if filter.MinVisits != nil {
+		locations = locations.Where(sq.GtOrEq{"visits": filter.MinVisits})
+	}
+	locations = locations.
+		OrderBy("avg_mark DESC NULLS LAST", "visits DESC", "location").
+		Limit(filter.GetLimit())
+
+	sql, args, err := locations.ToSql()
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	result := &LocationRanks{[]*LocationRank{}}
+	if err := d.Socket.Select(&result.Rows, sql, args...); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (d *Database) aggregateTopLocations(filter *LocationTopFilter) (*LocationRanks, error) {
+	locations := d.StatementBuilder.
+		Select(
+			"locations.id",
+			"place",
+			"country",
+			"city",
+			`"round"("avg"(visits.mark), 2) AS "avg"`,
+			"count(*) AS visits",
+		).
+		From(table(locationsTableName, filter.AsOf)).
+		Join(fmt.Sprintf("%s ON %s.id = %s.location", table(visitsTableName, filter.AsOf), locationsTableName, visitsTableName)).
+		Join(fmt.Sprintf(`%s ON %s."user" = %s.id`, table(usersTableName, filter.AsOf), visitsTableName, usersTableName))
+	locations = filterVisitors(locations, &filter.LocationFilter, filter.AsOf)
+
+	if filter.Country != nil {
+		locations = locations.Where(sq.Eq{"country": filter.Country})
+	}
+	if filter.City != nil {
+		locations = locations.Where(sq.Eq{"city": filter.City})
+	}
+
+	// Columns of locations restored from history are not functionally
+	// dependent on id, so all of them are grouped by.
+	locations = locations.
+		GroupBy("locations.id", "place", "country", "city").
+		Having("count(visits.mark) > 0")
+	if filter.MinVisits != nil {
+		locations = locations.Having("count(*) >= ?", filter.MinVisits)
+	}
+	locations = locations.
+		OrderBy(`"avg" DESC NULLS LAST`, "visits DESC", "locations.id").
+		Limit(filter.GetLimit())
+
+	sql, args, err := locations.ToSql()
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	result := &LocationRanks{[]*LocationRank{}}
+	if err := d.Socket.Select(&result.Rows, sql, args...); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetCountries returns countries with numbers of their locations and visits.
+func (d *Database) GetCountries() (*Countries, error) {
+	sql, args, err := d.StatementBuilder.
+		Select(
+			"country",
+			"count(DISTINCT locations.id) AS locations",
+			"count(visits.id) AS visits",
+		).
+		From(locationsTableName).
+		LeftJoin(fmt.Sprintf("%s ON %s.id = %s.location", visitsTableName, locationsTableName, visitsTableName)).
+		GroupBy("country").
+		OrderBy("country").
+		ToSql()
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	result := &Countries{[]*Country{}}
+	if err := d.Socket.Select(&result.Rows, sql, args...); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetLocationStats returns visits statistics for location specified by id.
+func (d *Database) GetLocationStats(id string, filter *LocationFilter) (*LocationStats, error) {
+	locations := d.StatementBuilder.
+		Select(
+			`count(*) AS "count"`,
+			`COALESCE("round"("avg"(visits.mark), 2), 0) AS "avg"`,
+			markHistogram,
+			`COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY visits.mark), 0) AS median`,
+			`COALESCE("round"(stddev_pop(visits.mark), 2), 0) AS stddev`,
+			"min(visits.visited_at) AS min_visited_at",
+			"max(visits.visited_at) AS max_visited_at",
+		).
+		From(locationsTableName).
+		Join(fmt.Sprintf("%s ON %s.id = %s.location", visitsTableName, locationsTableName, visitsTableName)).
+		Join(fmt.Sprintf(`%s ON %s."user" = %s.id`, usersTableName, visitsTableName, usersTableName)).
+		Where(sq.Eq{locationsTableName + ".id": id})
+	locations = filterVisitors(locations, filter, nil)
+
+	sql, args, err := locations.ToSql()
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	stats := &LocationStats{}
+	if err = d.Socket.Get(stats, sql, args...); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// GetLocationTimeline returns visits of location specified by id grouped into time buckets.
+func (d *Database) GetLocationTimeline(id string, filter *TimelineFilter) (*Timeline, error) {
+	bucket, timezone := filter.GetBucket(), filter.GetTimezone()
+	if !timelineBuckets[bucket] {
+		return nil, fmt.Errorf("unknown timeline bucket %q", bucket)
+	}
+
+	const start = "date_trunc(?, to_timestamp(visits.visited_at) AT TIME ZONE ?) AT TIME ZONE ?"
+
+	timeline := d.StatementBuilder.
+		Select().
+		Column(`extract(epoch FROM `+start+`)::bigint AS start`, bucket, timezone, timezone).
+		Columns(
+			"count(*) AS visits",
+			`COALESCE("round"("avg"(visits.mark), 2), 0) AS "avg"`,
+		).
+		From(visitsTableName).
+		Join(fmt.Sprintf(`%s ON %s."user" = %s.id`, usersTableName, visitsTableName, usersTableName)).
+		Where(sq.Eq{"location": id}).
+		GroupBy("1").
+		OrderBy("1")
+	timeline = filterVisitors(timeline, &filter.LocationFilter, nil)
+
+	sql, args, err := timeline.ToSql()
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	result := &Timeline{[]*TimelineBucket{}}
+	if err := d.Socket.Select(&result.Rows, sql, args...); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetLocationVisits returns location's visitors specified by location id from database.
+func (d *Database) GetLocationVisits(id string, filter *LocationFilter) (*Visitors, error) {
+	visitors := d.StatementBuilder.
+		Select(`users.id AS "user"`, "gender", userAge(nil)+"::integer AS age", "mark", "visited_at").
+		From(visitsTableName).
+		Join(fmt.Sprintf(`%s ON %s."user" = %s.id`, usersTableName, visitsTableName, usersTableName)).
+		Where(sq.Eq{"location": id}).
+		OrderBy("visited_at")
+	visitors = filterVisitors(visitors, filter, nil)
+
+	sql, args, err := visitors.ToSql()
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	result := &Visitors{[]*Visitor{}}
+	if err := d.Socket.Select(&result.Rows, sql, args...); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// InsertLocation inserts specified location into database.
+func (d *Database) InsertLocation(location *Location) error {
+	return d.write(func(e sqlx.Ext) error {
+		specified := location.ID != nil
+		if err := d.insertLocation(e, location); err != nil || !specified {
+			return err
 		}
+		return d.syncSequences(e, locationsTableName)
+	})
+}
+
+func (d *Database) insertLocation(e sqlx.Ext, location *Location) error {
+	id, err := d.insertRow(e, locationsTableName, locationsTableColumns, location.ID, location.Place, location.Country, location.City, location.Distance)
+	location.ID = id
+	return err
+}
+
+// PopulateLocations inserts specified list of locations into database.
+func (d *Database) PopulateLocations(locations *Locations) error {
+	rows := make([]interface{}, len(locations.Rows))
+	for i, location := range locations.Rows {
+		rows[i] = location
 	}
-	return nil
+	return d.populate(rows)
 }
 
 // UpdateLocation updates specified location's row in database.
 func (d *Database) UpdateLocation(id string, location *Location) error {
+	return d.write(func(e sqlx.Ext) error {
+		return d.updateLocation(e, id, location)
+	})
+}
+
+func (d *Database) updateLocation(e sqlx.Queryer, id string, location *Location) error {
 	update := d.StatementBuilder.Update(locationsTableName)
 
 	if location.Place != nil {
@@ -284,51 +1077,107 @@ func (d *Database) UpdateLocation(id string, location *Location) error {
 		update = update.Set("distance", location.Distance)
 	}
 
-	update = update.Where(sq.Eq{"id": id})
-
-	sql, args, err := update.ToSql()
-	if err != nil {
-		return err
-	}
-
-	_, err = d.Socket.Exec(sql, args...)
+	version, err := d.updateVersioned(e, locationsTableName, update, id, location.Version)
+	location.Version = version
 	return err
 }
 
-// GetVisit returns visit specified by id from database.
-func (d *Database) GetVisit(id string) (*Visit, error) {
+// DeleteLocation deletes location specified by id from database.
+// Location's visits are deleted too when cascade is set.
+func (d *Database) DeleteLocation(id string, cascade bool) error {
+	return d.deleteWithVisits(locationsTableName, "location", id, cascade)
+}
+
+// GetVisit returns visit specified by id from database, as it was at asOf
+// unix time when specified.
+func (d *Database) GetVisit(id string, asOf *int64) (*Visit, error) {
 	visit := new(Visit)
-	err := d.getByID(visitsTableName, id, visit)
+	err := d.getByID(visitsTableName, id, asOf, visit)
 	return visit, err
 }
 
-// InsertVisit inserts specified visit into database.
-func (d *Database) InsertVisit(visit *Visit) error {
-	sql, args, err := d.StatementBuilder.
-		Insert(visitsTableName).
-		Columns(visitsTableColumns...).
-		Values(visit.ID, visit.Location, visit.User, visit.VisitedAt, visit.Mark).
-		ToSql()
+// visitsQuery returns query selecting visits matching specified filter.
+func (d *Database) visitsQuery(filter *VisitListFilter) sq.SelectBuilder {
+	visits := d.StatementBuilder.Select(visitsTableColumns...).From(visitsTableName)
+
+	if filter.User != nil {
+		visits = visits.Where(sq.Eq{`"user"`: filter.User})
+	}
+	if filter.Location != nil {
+		visits = visits.Where(sq.Eq{"location": filter.Location})
+	}
+
+	if filter.FromDate != nil {
+		visits = visits.Where(sq.Gt{"visited_at": filter.FromDate})
+	}
+	if filter.ToDate != nil {
+		visits = visits.Where(sq.Lt{"visited_at": filter.ToDate})
+	}
+
+	if filter.FromMark != nil {
+		visits = visits.Where(sq.Gt{"mark": filter.FromMark})
+	}
+	if filter.ToMark != nil {
+		visits = visits.Where(sq.Lt{"mark": filter.ToMark})
+	}
+
+	return visits
+}
+
+// GetVisits returns page of visits matching specified filter from database.
+func (d *Database) GetVisits(filter *VisitListFilter) (*Visits, error) {
+	sql, args, err := d.paginate(d.visitsQuery(filter), &filter.Page).ToSql()
 	if err != nil {
-		return err
+		log.Println(err)
+		return nil, err
 	}
 
-	_, err = d.Socket.Exec(sql, args...)
+	result := &Visits{Rows: []*Visit{}}
+	if err := d.Socket.Select(&result.Rows, sql, args...); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	if n := len(result.Rows); n > 0 {
+		result.Next = filter.nextCursor(n, result.Rows[n-1].ID)
+	}
+	return result, nil
+}
+
+// InsertVisit inserts specified visit into database.
+func (d *Database) InsertVisit(visit *Visit) error {
+	return d.write(func(e sqlx.Ext) error {
+		specified := visit.ID != nil
+		if err := d.insertVisit(e, visit); err != nil || !specified {
+			return err
+		}
+		return d.syncSequences(e, visitsTableName)
+	})
+}
+
+func (d *Database) insertVisit(e sqlx.Ext, visit *Visit) error {
+	id, err := d.insertRow(e, visitsTableName, visitsTableColumns, visit.ID, visit.Location, visit.User, visit.VisitedAt, visit.Mark)
+	visit.ID = id
 	return err
 }
 
 // PopulateVisits inserts specified list of visits into database.
 func (d *Database) PopulateVisits(visits *Visits) error {
-	for _, visit := range visits.Rows {
-		if err := d.InsertVisit(visit); err != nil {
-			return err
-		}
+	rows := make([]interface{}, len(visits.Rows))
+	for i, visit := range visits.Rows {
+		rows[i] = visit
 	}
-	return nil
+	return d.populate(rows)
 }
 
 // UpdateVisit updates specified visit's row in database.
 func (d *Database) UpdateVisit(id string, visit *Visit) error {
+	return d.write(func(e sqlx.Ext) error {
+		return d.updateVisit(e, id, visit)
+	})
+}
+
+func (d *Database) updateVisit(e sqlx.Queryer, id string, visit *Visit) error {
 	update := d.StatementBuilder.Update(visitsTableName)
 
 	if visit.Location != nil {
@@ -344,9 +1193,457 @@ func (d *Database) UpdateVisit(id string, visit *Visit) error {
 		update = update.Set("mark", visit.Mark)
 	}
 
-	update = update.Where(sq.Eq{"id": id})
+	version, err := d.updateVersioned(e, visitsTableName, update, id, visit.Version)
+	visit.Version = version
+	return err
+}
+
+// DeleteVisit deletes visit specified by id from database.
+func (d *Database) DeleteVisit(id string) error {
+	return d.write(func(e sqlx.Ext) error {
+		return d.deleteByID(e, visitsTableName, id)
+	})
+}
+
+// recommend returns well-rated locations selected by candidates query.
+// Candidates query must select location id and its support.
+func (d *Database) recommend(candidates sq.SelectBuilder, filter *RecommendationFilter) (*Recommendations, error) {
+	sql, args, err := d.StatementBuilder.
+		Select(
+			"locations.id",
+			"place",
+			"country",
+			"city",
+			"candidates.support",
+			`"round"("avg"(visits.mark), 2) AS "avg"`,
+		).
+		FromSelect(candidates, "candidates").
+		Join(fmt.Sprintf("%s ON %s.id = candidates.id", locationsTableName, locationsTableName)).
+		Join(fmt.Sprintf("%s ON %s.location = candidates.id", visitsTableName, visitsTableName)).
+		GroupBy("locations.id", "candidates.support").
+		Having(`"avg"(visits.mark) >= ?`, filter.GetMinAvg()).
+		OrderBy("candidates.support DESC", `"avg" DESC`, "locations.id").
+		Limit(filter.GetLimit()).
+		ToSql()
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	result := &Recommendations{[]*Recommendation{}}
+	if err := d.Socket.Select(&result.Rows, sql, args...); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetRelatedLocations returns well-rated locations visited by users
+// who also visited location specified by id.
+func (d *Database) GetRelatedLocations(id string, filter *RecommendationFilter) (*Recommendations, error) {
+	candidates := sq.
+		Select("related AS id", "users AS support").
+		From(coVisitsTableName).
+		Where(sq.Eq{"location": id}).
+		Where(sq.GtOrEq{"users": filter.GetMinSupport()})
+
+	return d.recommend(candidates, filter)
+}
+
+// GetUserRecommendations returns well-rated locations not visited yet by user
+// specified by id, but visited by users who share visited locations with the user.
+func (d *Database) GetUserRecommendations(id string, filter *RecommendationFilter) (*Recommendations, error) {
+	visited := fmt.Sprintf(`SELECT location FROM %s WHERE "user" = ?`, visitsTableName)
+
+	candidates := sq.
+		Select("related AS id", "sum(users) AS support").
+		From(coVisitsTableName).
+		Where("location IN ("+visited+")", id).
+		Where("related NOT IN ("+visited+")", id).
+		GroupBy("related").
+		Having("sum(users) >= ?", filter.GetMinSupport())
+
+	return d.recommend(candidates, filter)
+}
+
+// errBadOperation wraps errors caused by malformed batch operation.
+type errBadOperation struct {
+	error
+}
+
+func (d *Database) applyOperation(e sqlx.Ext, op *BatchOperation) error {
+	if op == nil {
+		return errBadOperation{errors.New("missing operation")}
+	}
+
+	var id string
+	switch op.Action {
+	case batchActionCreate:
+	case batchActionUpdate:
+		if op.ID == nil {
+			return errBadOperation{errors.New("missing id")}
+		}
+		id = strconv.FormatUint(uint64(*op.ID), 10)
+	default:
+		return errBadOperation{fmt.Errorf("unknown action %q", op.Action)}
+	}
+
+	var entity interface{}
+	switch op.Entity {
+	case usersTableName:
+		entity = new(User)
+	case locationsTableName:
+		entity = new(Location)
+	case visitsTableName:
+		entity = new(Visit)
+	default:
+		return errBadOperation{fmt.Errorf("unknown entity %q", op.Entity)}
+	}
+	if err := json.Unmarshal(op.Data, entity); err != nil {
+		return errBadOperation{err}
+	}
+
+	switch v := entity.(type) {
+	case *User:
+		if op.Action == batchActionCreate {
+			return d.insertUser(e, v)
+		}
+		return d.updateUser(e, id, v)
+	case *Location:
+		if op.Action == batchActionCreate {
+			return d.insertLocation(e, v)
+		}
+		return d.updateLocation(e, id, v)
+	case *Visit:
+		if op.Action == batchActionCreate {
+			return d.insertVisit(e, v)
+		}
+		return d.updateVisit(e, id, v)
+	}
+	return nil
+}
+
+// applyAll applies n operations in order inside a single transaction and
+// returns their errors. Failed operations are rolled back to their savepoints
+// unless atomic is set, in which case the whole transaction is rolled back
+// and other operations fail with ErrRolledBack. Id sequences are synced with
+// ids specified by operations once before commit.
+func (d *Database) applyAll(n int, atomic bool, apply func(e sqlx.Ext, i int) error) ([]error, error) {
+	tx, err := d.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	errs := make([]error, n)
+	for i := range errs {
+		if _, err := tx.Exec("SAVEPOINT operation"); err != nil {
+			return nil, err
+		}
+
+		if errs[i] = apply(tx, i); errs[i] == nil {
+			if _, err := tx.Exec("RELEASE SAVEPOINT operation"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if atomic {
+			for j := range errs {
+				if j != i {
+					errs[j] = ErrRolledBack
+				}
+			}
+			return errs, nil
+		}
+
+		if _, err := tx.Exec("ROLLBACK TO SAVEPOINT operation"); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := d.syncSequences(tx, usersTableName, locationsTableName, visitsTableName); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return errs, nil
+}
+
+// ApplyBatch applies batch operations in order inside a single transaction
+// and returns their errors.
+func (d *Database) ApplyBatch(batch *Batch) ([]error, error) {
+	return d.applyAll(len(batch.Operations), batch.Atomic, func(e sqlx.Ext, i int) error {
+		return d.applyOperation(e, batch.Operations[i])
+	})
+}
+
+// InsertRows inserts users, locations or visits inside a single transaction
+// and returns their errors. Failed rows do not prevent insertion of others.
+func (d *Database) InsertRows(rows []interface{}) ([]error, error) {
+	return d.applyAll(len(rows), false, func(e sqlx.Ext, i int) error {
+		return d.insertEntity(e, rows[i])
+	})
+}
+
+// insertEntity inserts user, location or visit.
+func (d *Database) insertEntity(e sqlx.Ext, row interface{}) error {
+	switch row := row.(type) {
+	case *User:
+		return d.insertUser(e, row)
+	case *Location:
+		return d.insertLocation(e, row)
+	case *Visit:
+		return d.insertVisit(e, row)
+	}
+	return fmt.Errorf("unsupported row type %T", row)
+}
+
+// populate inserts rows into database inside a single transaction, nothing
+// is inserted when any of rows fails.
+func (d *Database) populate(rows []interface{}) error {
+	return d.write(func(e sqlx.Ext) error {
+		// Loaded rows are not recorded to history, see schema.
+		if _, err := e.Exec("SELECT set_config('app.loading', 'on', true)"); err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := d.insertEntity(e, row); err != nil {
+				return err
+			}
+		}
+		return d.syncSequences(e, usersTableName, locationsTableName, visitsTableName)
+	})
+}
+
+// export iterates over rows selected by query ordered by id without
+// buffering them and passes every row created by newRow to fn. Rows start
+// after the page cursor and are not limited unless the page limit is set.
+func (d *Database) export(query sq.SelectBuilder, page *Page, newRow func() interface{}, fn func(row interface{}) error) error {
+	if page.After != nil {
+		query = query.Where(sq.Gt{"id": page.After})
+	}
+	if page.Limit != nil {
+		query = query.Limit(*page.Limit)
+	}
+
+	sql, args, err := query.Column("version").OrderBy("id").ToSql()
+	if err != nil {
+		return err
+	}
+
+	rows, err := d.Socket.Queryx(sql, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
 
-	sql, args, err := update.ToSql()
+	for rows.Next() {
+		row := newRow()
+		if err := rows.StructScan(row); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ExportUsers passes every user matching specified filter to fn.
+func (d *Database) ExportUsers(filter *UserListFilter, fn func(row interface{}) error) error {
+	return d.export(d.usersQuery(filter), &filter.Page, func() interface{} { return new(User) }, fn)
+}
+
+// ExportLocations passes every location matching specified filter to fn.
+func (d *Database) ExportLocations(filter *LocationListFilter, fn func(row interface{}) error) error {
+	return d.export(d.locationsQuery(filter), &filter.Page, func() interface{} { return new(Location) }, fn)
+}
+
+// ExportVisits passes every visit matching specified filter to fn.
+func (d *Database) ExportVisits(filter *VisitListFilter, fn func(row interface{}) error) error {
+	return d.export(d.visitsQuery(filter), &filter.Page, func() interface{} { return new(Visit) }, fn)
+}
+
+// GetHistory returns changes of the entity row specified by table and id.
+// ErrNotFound is returned when no changes are recorded.
+func (d *Database) GetHistory(table string, id string) (*History, error) {
+	sql, args, err := d.StatementBuilder.
+		Select("seq", "operation", "changed_at", "request_id", "old", "new").
+		From(historyTableName).
+		Where(sq.Eq{"entity": table, "entity_id": id}).
+		Where(sq.NotEq{"operation": "load"}).
+		OrderBy("seq").
+		ToSql()
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	result := &History{[]*Change{}}
+	if err := d.Socket.Select(&result.Rows, sql, args...); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	if len(result.Rows) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return result, nil
+}
+
+// GetLastChange returns sequence number of the last change recorded in the history.
+func (d *Database) GetLastChange() (uint64, error) {
+	sql, args, err := d.StatementBuilder.
+		Select("COALESCE(max(seq), 0)").
+		From(historyTableName).
+		ToSql()
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	var result uint64
+	if err := d.Socket.Get(&result, sql, args...); err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	return result, nil
+}
+
+// GetChanges returns creations and updates of entities recorded in the history
+// after the specified sequence number. Sequence numbers are allocated in commit
+// order, see history_lock in schema, so later changes never get lower ones.
+func (d *Database) GetChanges(after uint64, limit uint64) ([]*ChangeEvent, error) {
+	sql, args, err := d.StatementBuilder.
+		Select("seq", "entity", "entity_id", "operation", "new").
+		From(historyTableName).
+		Where(sq.Gt{"seq": after}).
+		Where(sq.Eq{"operation": []string{"create", "update"}}).
+		OrderBy("seq").
+		Limit(limit).
+		ToSql()
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	var result []*ChangeEvent
+	if err := d.Socket.Select(&result, sql, args...); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// InsertWebhook adds webhook to database or updates secret and entities of
+// the webhook with the same URL. Empty entities subscribe to all entities.
+func (d *Database) InsertWebhook(webhook *Webhook) error {
+	secret := ""
+	if webhook.Secret != nil {
+		secret = *webhook.Secret
+	}
+	if len(webhook.Entities) == 0 {
+		webhook.Entities = nil
+	}
+
+	sql, args, err := d.StatementBuilder.
+		Insert(webhooksTableName).
+		Columns("url", "secret", "entities").
+		Values(webhook.URL, secret, webhook.Entities).
+		Suffix("ON CONFLICT (url) DO UPDATE SET secret = EXCLUDED.secret, entities = EXCLUDED.entities RETURNING id").
+		ToSql()
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	if err := d.Socket.Get(&webhook.ID, sql, args...); err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetWebhooks returns webhooks from database without their secrets.
+func (d *Database) GetWebhooks() (*Webhooks, error) {
+	sql, args, err := d.StatementBuilder.
+		Select("id", "url", "entities").
+		From(webhooksTableName).
+		OrderBy("id").
+		ToSql()
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	result := &Webhooks{[]*Webhook{}}
+	if err := d.Socket.Select(&result.Rows, sql, args...); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DeleteWebhook deletes webhook specified by id along with its pending deliveries.
+func (d *Database) DeleteWebhook(id string) error {
+	return d.deleteByID(d.Socket, webhooksTableName, id)
+}
+
+// claimWebhookDeliveries returns due deliveries counting their attempts.
+// Claimed deliveries are postponed by lease, so they are not claimed again
+// while being delivered.
+func (d *Database) claimWebhookDeliveries(limit int, lease time.Duration) ([]*webhookDelivery, error) {
+	sql := fmt.Sprintf(`WITH claimed AS (
+		UPDATE %[1]s SET attempts = attempts + 1, next_attempt_at = now() + make_interval(secs => $1)
+		WHERE id IN (
+			SELECT id FROM %[1]s WHERE next_attempt_at <= now()
+			ORDER BY id LIMIT $2 FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, webhook, attempts, seq, entity, entity_id, operation, new
+	)
+	SELECT claimed.*, %[2]s.url, %[2]s.secret
+	FROM claimed
+	JOIN %[2]s ON %[2]s.id = claimed.webhook
+	ORDER BY claimed.id`, webhookDeliveriesTableName, webhooksTableName)
+
+	var result []*webhookDelivery
+	if err := d.Socket.Select(&result, sql, lease.Seconds(), limit); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// completeWebhookDelivery removes delivery from the queue.
+func (d *Database) completeWebhookDelivery(delivery *webhookDelivery) error {
+	sql, args, err := d.StatementBuilder.
+		Delete(webhookDeliveriesTableName).
+		Where(sq.Eq{"id": delivery.ID}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = d.Socket.Exec(sql, args...)
+	return err
+}
+
+// retryWebhookDelivery postpones failed delivery by delay.
+func (d *Database) retryWebhookDelivery(delivery *webhookDelivery, delay time.Duration, cause error) error {
+	sql, args, err := d.StatementBuilder.
+		Update(webhookDeliveriesTableName).
+		Set("next_attempt_at", sq.Expr("now() + make_interval(secs => ?)", delay.Seconds())).
+		Set("last_error", cause.Error()).
+		Where(sq.Eq{"id": delivery.ID}).
+		ToSql()
 	if err != nil {
 		return err
 	}
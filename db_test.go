@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+)
+
+// testDSNEnv names environment variable with source name of the database used
+// by tests. The schema of the database is recreated, so it must be disposable.
+const testDSNEnv = "HLCUP_TEST_DSN"
+
+// testDatabase returns database with fresh schema, the test is skipped when
+// the test database is not configured.
+func testDatabase(t *testing.T) *Database {
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skip(testDSNEnv + " is not set")
+	}
+
+	socket, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { socket.Close() })
+
+	d := &Database{
+		Socket:           socket,
+		StatementBuilder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+	if err := d.createSchema("schema.sql"); err != nil {
+		t.Fatal(err)
+	}
+	socket.MustExec("TRUNCATE webhooks, webhook_deliveries")
+	return d
+}
+
+func testUser(email string) *User {
+	name, gender, birthDate := "Test", "f", int32(0)
+	return &User{Email: &email, FirstName: &name, LastName: &name, Gender: &gender, BirthDate: &birthDate}
+}
+
+// testApp returns application serving the test database.
+func testApp(t *testing.T) *App {
+	a := &App{
+		Router:      mux.NewRouter(),
+		Database:    testDatabase(t),
+		Config:      &Config{DeletePolicy: deletePolicyReject},
+		idempotency: newIdempotencyStore(time.Minute, 10),
+		changes:     newChangeFeed(),
+	}
+	a.Router.Use(requestIDMiddleware)
+	a.initializeRoutes()
+	return a
+}
+
+// serve returns response of the application to the request.
+func serve(a *App, method string, url string, body io.Reader) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, httptest.NewRequest(method, url, body))
+	return w
+}
+
+func testLocation(place string) *Location {
+	country, city, distance := "Russia", "Moscow", uint32(10)
+	return &Location{Place: &place, Country: &country, City: &city, Distance: &distance}
+}
+
+func testVisit(location, user *uint32, mark uint8) *Visit {
+	visitedAt := int32(1000)
+	return &Visit{Location: location, User: user, VisitedAt: &visitedAt, Mark: &mark}
+}
+
+// TestSyncSequences checks that ids are assigned from 1 and after the ids
+// specified by clients.
+func TestSyncSequences(t *testing.T) {
+	d := testDatabase(t)
+
+	first := testUser("first@example.com")
+	if err := d.InsertUser(first); err != nil {
+		t.Fatal(err)
+	}
+	if *first.ID != 1 {
+		t.Errorf("first user got id %d, want 1", *first.ID)
+	}
+
+	specified := testUser("specified@example.com")
+	specified.ID = new(uint32)
+	*specified.ID = 10
+	if err := d.InsertUser(specified); err != nil {
+		t.Fatal(err)
+	}
+
+	next := testUser("next@example.com")
+	if err := d.InsertUser(next); err != nil {
+		t.Fatal(err)
+	}
+	if *next.ID != 11 {
+		t.Errorf("user after specified id got id %d, want 11", *next.ID)
+	}
+
+	duplicate := testUser("duplicate@example.com")
+	duplicate.ID = specified.ID
+	if err := d.InsertUser(duplicate); err != ErrDuplicate {
+		t.Errorf("insert of duplicate id failed with %v, want %v", err, ErrDuplicate)
+	}
+}
+
+// TestUpdateVersioned checks that update with expected version distinguishes
+// missing rows from version conflicts.
+func TestUpdateVersioned(t *testing.T) {
+	d := testDatabase(t)
+
+	user := testUser("versioned@example.com")
+	if err := d.InsertUser(user); err != nil {
+		t.Fatal(err)
+	}
+
+	stale := uint64(100)
+	update := &User{Version: &stale}
+	if err := d.UpdateUser(fmt.Sprint(*user.ID), update); err != ErrConflict {
+		t.Errorf("update with stale version failed with %v, want %v", err, ErrConflict)
+	}
+	if err := d.UpdateUser(fmt.Sprint(*user.ID+1), update); err != ErrNotFound {
+		t.Errorf("update of missing user failed with %v, want %v", err, ErrNotFound)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Export formats.
+const (
+	exportFormatNDJSON = "ndjson"
+	exportFormatCSV    = "csv"
+)
+
+// rowWriter writes exported rows in some format.
+type rowWriter interface {
+	Write(row interface{}) error
+	Flush() error
+}
+
+// newRowWriter returns writer of rows of the same type as the specified one
+// in specified format with its content type.
+func newRowWriter(format string, w io.Writer, row interface{}) (rowWriter, string, error) {
+	switch format {
+	case "", exportFormatNDJSON:
+		return &ndjsonWriter{json.NewEncoder(w)}, contentTypeNDJSON, nil
+	case exportFormatCSV:
+		return &csvWriter{writer: csv.NewWriter(w), header: csvHeader(row)}, contentTypeCSV, nil
+	}
+	return nil, "", fmt.Errorf("unknown export format %q", format)
+}
+
+// trackingWriter records whether anything has been written through it.
+type trackingWriter struct {
+	io.Writer
+	written bool
+}
+
+func (t *trackingWriter) Write(b []byte) (int, error) {
+	if len(b) > 0 {
+		t.written = true
+	}
+	return t.Writer.Write(b)
+}
+
+// ndjsonWriter writes rows as newline-delimited JSON.
+type ndjsonWriter struct {
+	encoder *json.Encoder
+}
+
+func (n *ndjsonWriter) Write(row interface{}) error {
+	return n.encoder.Encode(row)
+}
+
+func (n *ndjsonWriter) Flush() error {
+	return nil
+}
+
+// csvWriter writes rows as CSV preceded by the header.
+type csvWriter struct {
+	writer  *csv.Writer
+	header  []string
+	started bool
+}
+
+func (c *csvWriter) start() error {
+	if c.started {
+		return nil
+	}
+	c.started = true
+	return c.writer.Write(c.header)
+}
+
+func (c *csvWriter) Write(row interface{}) error {
+	if err := c.start(); err != nil {
+		return err
+	}
+	return c.writer.Write(csvRecord(row))
+}
+
+func (c *csvWriter) Flush() error {
+	if err := c.start(); err != nil {
+		return err
+	}
+	c.writer.Flush()
+	return c.writer.Error()
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// TestExportPage checks that export starts after the cursor, stops at the
+// limit and fills every column of the CSV header.
+func TestExportPage(t *testing.T) {
+	a := testApp(t)
+
+	for i := 0; i < 3; i++ {
+		if err := a.Database.InsertUser(testUser(fmt.Sprintf("export%d@example.com", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	url := "/export/users?format=csv&after=1&limit=1"
+	w := serve(a, http.MethodGet, url, nil)
+	want := "id,email,first_name,last_name,gender,birth_date,version\n" +
+		"2,export1@example.com,Test,Test,f,0,1\n"
+	if w.Code != http.StatusOK || w.Body.String() != want {
+		t.Errorf("GET %s responded with %d %q, want %d %q", url, w.Code, w.Body, http.StatusOK, want)
+	}
+}
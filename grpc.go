@@ -0,0 +1,400 @@
+package main
+
+//go:generate protoc --go_out=plugins=grpc:. api/hlcup.proto
+
+import (
+	dbsql "database/sql"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"strconv"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/lzakharov/hlcup2017/api"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// grpcServer implements gRPC API over the same database as REST handlers.
+type grpcServer struct {
+	app *App
+}
+
+// serveGRPC serves gRPC API on specified address.
+func (a *App) serveGRPC(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	server := grpc.NewServer()
+	api.RegisterHLCupServer(server, &grpcServer{a})
+	return server.Serve(listener)
+}
+
+// database returns database attributing changes to the request id from
+// call metadata, the id is generated when absent and sent back in header.
+func (s *grpcServer) database(ctx context.Context) *Database {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDHeader); len(values) > 0 {
+			id = values[0]
+		}
+	}
+	if id == "" {
+		id = newRequestID()
+	}
+
+	if err := grpc.SetHeader(ctx, metadata.Pairs(requestIDHeader, id)); err != nil {
+		log.Println(err)
+	}
+	return s.app.Database.Request(id)
+}
+
+// grpcError returns gRPC status corresponding to the database error,
+// unknown errors get the fallback code.
+func grpcError(err error, fallback codes.Code) error {
+	switch err {
+	case ErrNotFound, dbsql.ErrNoRows:
+		return status.Error(codes.NotFound, err.Error())
+	case ErrConflict:
+		return status.Error(codes.Aborted, err.Error())
+	case ErrDuplicate:
+		return status.Error(codes.AlreadyExists, err.Error())
+	}
+	return status.Error(fallback, err.Error())
+}
+
+func (s *grpcServer) GetUser(ctx context.Context, req *api.GetRequest) (*api.User, error) {
+	user, err := s.app.Database.GetUser(formatID(req.Id), int64Ptr(req.AsOf))
+	if err != nil {
+		return nil, grpcError(err, codes.NotFound)
+	}
+	return userToProto(user), nil
+}
+
+func (s *grpcServer) CreateUser(ctx context.Context, req *api.User) (*api.Created, error) {
+	user := userFromProto(req)
+	if err := s.database(ctx).InsertUser(user); err != nil {
+		return nil, grpcError(err, codes.Internal)
+	}
+	s.app.changes.publish()
+
+	return &api.Created{Id: *user.ID}, nil
+}
+
+func (s *grpcServer) UpdateUser(ctx context.Context, req *api.UpdateUserRequest) (*api.Updated, error) {
+	user := userFromProto(req.User)
+	if err := s.database(ctx).UpdateUser(formatID(req.Id), user); err != nil {
+		return nil, grpcError(err, codes.Internal)
+	}
+	s.app.changes.publish()
+
+	return &api.Updated{Version: *user.Version}, nil
+}
+
+func (s *grpcServer) ListUserVisits(ctx context.Context, req *api.ListUserVisitsRequest) (*api.Places, error) {
+	filter := new(UserVisitsFilter)
+	if f := req.Filter; f != nil {
+		filter.FromDate = int32Ptr(f.FromDate)
+		filter.ToDate = int32Ptr(f.ToDate)
+		filter.Country = stringPtr(f.Country)
+		filter.Distance = uint32Ptr(f.Distance)
+		filter.AsOf = int64Ptr(f.AsOf)
+	}
+
+	places, err := s.app.Database.GetUserVisits(formatID(req.Id), filter)
+	if err != nil {
+		return nil, grpcError(err, codes.InvalidArgument)
+	}
+
+	result := &api.Places{Visits: make([]*api.Place, len(places.Rows))}
+	for i, place := range places.Rows {
+		result.Visits[i] = &api.Place{
+			Mark:      uint32(place.Mark),
+			VisitedAt: place.VisitedAt,
+			Place:     place.Place,
+		}
+	}
+	return result, nil
+}
+
+func (s *grpcServer) GetLocation(ctx context.Context, req *api.GetRequest) (*api.Location, error) {
+	location, err := s.app.Database.GetLocation(formatID(req.Id), int64Ptr(req.AsOf))
+	if err != nil {
+		return nil, grpcError(err, codes.NotFound)
+	}
+	return locationToProto(location), nil
+}
+
+func (s *grpcServer) CreateLocation(ctx context.Context, req *api.Location) (*api.Created, error) {
+	location := locationFromProto(req)
+	if err := s.database(ctx).InsertLocation(location); err != nil {
+		return nil, grpcError(err, codes.Internal)
+	}
+	s.app.changes.publish()
+
+	return &api.Created{Id: *location.ID}, nil
+}
+
+func (s *grpcServer) UpdateLocation(ctx context.Context, req *api.UpdateLocationRequest) (*api.Updated, error) {
+	location := locationFromProto(req.Location)
+	if err := s.database(ctx).UpdateLocation(formatID(req.Id), location); err != nil {
+		return nil, grpcError(err, codes.Internal)
+	}
+	s.app.changes.publish()
+
+	return &api.Updated{Version: *location.Version}, nil
+}
+
+func (s *grpcServer) GetLocationAverageMark(ctx context.Context, req *api.GetLocationAverageMarkRequest) (*api.LocationAvgMark, error) {
+	filter := new(LocationAvgFilter)
+	if f := req.Filter; f != nil {
+		filter.FromDate = int32Ptr(f.FromDate)
+		filter.ToDate = int32Ptr(f.ToDate)
+		filter.FromAge = int32Ptr(f.FromAge)
+		filter.ToAge = int32Ptr(f.ToAge)
+		filter.Gender = stringPtr(f.Gender)
+		filter.AsOf = int64Ptr(f.AsOf)
+	}
+
+	avg, err := s.app.Database.GetLocationAverageMark(formatID(req.Id), filter)
+	if err != nil {
+		return nil, grpcError(err, codes.InvalidArgument)
+	}
+	return &api.LocationAvgMark{Avg: avg.Avg}, nil
+}
+
+func (s *grpcServer) GetVisit(ctx context.Context, req *api.GetRequest) (*api.Visit, error) {
+	visit, err := s.app.Database.GetVisit(formatID(req.Id), int64Ptr(req.AsOf))
+	if err != nil {
+		return nil, grpcError(err, codes.NotFound)
+	}
+	return visitToProto(visit), nil
+}
+
+func (s *grpcServer) CreateVisit(ctx context.Context, req *api.Visit) (*api.Created, error) {
+	visit, err := visitFromProto(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := s.database(ctx).InsertVisit(visit); err != nil {
+		return nil, grpcError(err, codes.Internal)
+	}
+	s.app.changes.publish()
+
+	return &api.Created{Id: *visit.ID}, nil
+}
+
+func (s *grpcServer) UpdateVisit(ctx context.Context, req *api.UpdateVisitRequest) (*api.Updated, error) {
+	visit, err := visitFromProto(req.Visit)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := s.database(ctx).UpdateVisit(formatID(req.Id), visit); err != nil {
+		return nil, grpcError(err, codes.Internal)
+	}
+	s.app.changes.publish()
+
+	return &api.Updated{Version: *visit.Version}, nil
+}
+
+func (s *grpcServer) IngestVisits(stream api.HLCup_IngestVisitsServer) error {
+	inserter := newBulkInserter(s.database(stream.Context()), s.app.changes)
+
+	for line := 1; ; line++ {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		visit, err := visitFromProto(req)
+		if err != nil {
+			inserter.result.reject(line, err)
+			continue
+		}
+
+		if err := inserter.insert(line, visit); err != nil {
+			return grpcError(err, codes.Internal)
+		}
+	}
+
+	if err := inserter.flush(); err != nil {
+		return grpcError(err, codes.Internal)
+	}
+
+	result := &api.BulkResult{
+		Accepted: int32(inserter.result.Accepted),
+		Rejected: int32(inserter.result.Rejected),
+		Errors:   make([]*api.BulkError, len(inserter.result.Errors)),
+	}
+	for i, e := range inserter.result.Errors {
+		result.Errors[i] = &api.BulkError{Line: int32(e.Line), Error: e.Error}
+	}
+	return stream.SendAndClose(result)
+}
+
+func userFromProto(u *api.User) *User {
+	if u == nil {
+		return new(User)
+	}
+	return &User{
+		ID:        uint32Ptr(u.Id),
+		Email:     stringPtr(u.Email),
+		FirstName: stringPtr(u.FirstName),
+		LastName:  stringPtr(u.LastName),
+		Gender:    stringPtr(u.Gender),
+		BirthDate: int32Ptr(u.BirthDate),
+		Version:   uint64Ptr(u.Version),
+	}
+}
+
+func userToProto(u *User) *api.User {
+	return &api.User{
+		Id:        uint32Value(u.ID),
+		Email:     stringValue(u.Email),
+		FirstName: stringValue(u.FirstName),
+		LastName:  stringValue(u.LastName),
+		Gender:    stringValue(u.Gender),
+		BirthDate: int32Value(u.BirthDate),
+		Version:   uint64Value(u.Version),
+	}
+}
+
+func locationFromProto(l *api.Location) *Location {
+	if l == nil {
+		return new(Location)
+	}
+	return &Location{
+		ID:       uint32Ptr(l.Id),
+		Place:    stringPtr(l.Place),
+		Country:  stringPtr(l.Country),
+		City:     stringPtr(l.City),
+		Distance: uint32Ptr(l.Distance),
+		Version:  uint64Ptr(l.Version),
+	}
+}
+
+func locationToProto(l *Location) *api.Location {
+	return &api.Location{
+		Id:       uint32Value(l.ID),
+		Place:    stringValue(l.Place),
+		Country:  stringValue(l.Country),
+		City:     stringValue(l.City),
+		Distance: uint32Value(l.Distance),
+		Version:  uint64Value(l.Version),
+	}
+}
+
+func visitFromProto(v *api.Visit) (*Visit, error) {
+	if v == nil {
+		return new(Visit), nil
+	}
+
+	visit := &Visit{
+		ID:        uint32Ptr(v.Id),
+		Location:  uint32Ptr(v.Location),
+		User:      uint32Ptr(v.User),
+		VisitedAt: int32Ptr(v.VisitedAt),
+		Version:   uint64Ptr(v.Version),
+	}
+	if v.Mark != nil {
+		if v.Mark.Value > math.MaxUint8 {
+			return nil, fmt.Errorf("mark %d out of range", v.Mark.Value)
+		}
+		mark := uint8(v.Mark.Value)
+		visit.Mark = &mark
+	}
+	return visit, nil
+}
+
+func visitToProto(v *Visit) *api.Visit {
+	visit := &api.Visit{
+		Id:        uint32Value(v.ID),
+		Location:  uint32Value(v.Location),
+		User:      uint32Value(v.User),
+		VisitedAt: int32Value(v.VisitedAt),
+		Version:   uint64Value(v.Version),
+	}
+	if v.Mark != nil {
+		visit.Mark = &wrappers.UInt32Value{Value: uint32(*v.Mark)}
+	}
+	return visit
+}
+
+func formatID(id uint32) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+func stringPtr(v *wrappers.StringValue) *string {
+	if v == nil {
+		return nil
+	}
+	return &v.Value
+}
+
+func int32Ptr(v *wrappers.Int32Value) *int32 {
+	if v == nil {
+		return nil
+	}
+	return &v.Value
+}
+
+func int64Ptr(v *wrappers.Int64Value) *int64 {
+	if v == nil {
+		return nil
+	}
+	return &v.Value
+}
+
+func uint32Ptr(v *wrappers.UInt32Value) *uint32 {
+	if v == nil {
+		return nil
+	}
+	return &v.Value
+}
+
+func uint64Ptr(v *wrappers.UInt64Value) *uint64 {
+	if v == nil {
+		return nil
+	}
+	return &v.Value
+}
+
+func stringValue(v *string) *wrappers.StringValue {
+	if v == nil {
+		return nil
+	}
+	return &wrappers.StringValue{Value: *v}
+}
+
+func int32Value(v *int32) *wrappers.Int32Value {
+	if v == nil {
+		return nil
+	}
+	return &wrappers.Int32Value{Value: *v}
+}
+
+func uint32Value(v *uint32) *wrappers.UInt32Value {
+	if v == nil {
+		return nil
+	}
+	return &wrappers.UInt32Value{Value: *v}
+}
+
+func uint64Value(v *uint64) *wrappers.UInt64Value {
+	if v == nil {
+		return nil
+	}
+	return &wrappers.UInt64Value{Value: *v}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// JSONB contains raw JSON value scanned from jsonb column.
+type JSONB json.RawMessage
+
+// Scan implements the sql.Scanner interface.
+func (j *JSONB) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*j = nil
+	case []byte:
+		*j = append((*j)[:0], v...)
+	case string:
+		*j = JSONB(v)
+	default:
+		return fmt.Errorf("unsupported jsonb value %T", src)
+	}
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (j JSONB) MarshalJSON() ([]byte, error) {
+	if j == nil {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+// Change contains old and new values of the entity fields changed by the operation.
+type Change struct {
+	Seq       uint64    `json:"seq" db:"seq"`
+	Operation string    `json:"operation" db:"operation"`
+	ChangedAt time.Time `json:"changed_at" db:"changed_at"`
+	RequestID *string   `json:"request_id" db:"request_id"`
+	Old       JSONB     `json:"old" db:"old"`
+	New       JSONB     `json:"new" db:"new"`
+}
+
+// History contains slice of entity changes in order they were made.
+type History struct {
+	Rows []*Change `json:"history"`
+}
+
+// AsOfFilter contains point in time parameter from requests. When set, data
+// is restored from the history as it was at the specified unix time.
+type AsOfFilter struct {
+	AsOf *int64 `schema:"asOf"`
+}
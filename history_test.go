@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestAsOf checks that endpoints supporting asOf answer from the state at
+// that time and others reject it.
+func TestAsOf(t *testing.T) {
+	a := testApp(t)
+	d := a.Database
+
+	user := testUser("user@example.com")
+	location := testLocation("Cafe")
+	if err := d.InsertUser(user); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.InsertLocation(location); err != nil {
+		t.Fatal(err)
+	}
+	visit := testVisit(location.ID, user.ID, 5)
+	if err := d.InsertVisit(visit); err != nil {
+		t.Fatal(err)
+	}
+
+	// The state above is moved an hour back, the changes below are current.
+	d.Socket.MustExec("UPDATE history SET changed_at = changed_at - interval '1 hour'")
+	before := time.Now().Add(-2 * time.Hour).Unix()
+	asOf := time.Now().Add(-30 * time.Minute).Unix()
+
+	firstName, place, mark := "Changed", "Bar", uint8(1)
+	if err := d.UpdateUser(fmt.Sprint(*user.ID), &User{FirstName: &firstName}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.UpdateLocation(fmt.Sprint(*location.ID), &Location{Place: &place}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.UpdateVisit(fmt.Sprint(*visit.ID), &Visit{Mark: &mark}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url    string
+		status int
+		dest   interface{}
+		check  func(dest interface{}) bool
+	}{
+		{
+			url:    fmt.Sprintf("/users/%d?asOf=%d", *user.ID, asOf),
+			status: http.StatusOK,
+			dest:   new(User),
+			check:  func(dest interface{}) bool { return *dest.(*User).FirstName == "Test" },
+		},
+		{
+			url:    fmt.Sprintf("/users/%d?asOf=%d", *user.ID, before),
+			status: http.StatusNotFound,
+		},
+		{
+			url:    fmt.Sprintf("/locations/%d?asOf=%d", *location.ID, asOf),
+			status: http.StatusOK,
+			dest:   new(Location),
+			check:  func(dest interface{}) bool { return *dest.(*Location).Place == "Cafe" },
+		},
+		{
+			url:    fmt.Sprintf("/visits/%d?asOf=%d", *visit.ID, asOf),
+			status: http.StatusOK,
+			dest:   new(Visit),
+			check:  func(dest interface{}) bool { return *dest.(*Visit).Mark == 5 },
+		},
+		{
+			url:    fmt.Sprintf("/users/%d/visits?asOf=%d", *user.ID, asOf),
+			status: http.StatusOK,
+			dest:   new(Places),
+			check: func(dest interface{}) bool {
+				places := dest.(*Places).Rows
+				return len(places) == 1 && places[0].Mark == 5 && places[0].Place == "Cafe"
+			},
+		},
+		{
+			url:    fmt.Sprintf("/locations/%d/avg?asOf=%d", *location.ID, asOf),
+			status: http.StatusOK,
+			dest:   new(LocationAvgMark),
+			check:  func(dest interface{}) bool { return dest.(*LocationAvgMark).Avg == 5 },
+		},
+		{
+			url:    fmt.Sprintf("/locations/top?asOf=%d", asOf),
+			status: http.StatusOK,
+			dest:   new(LocationRanks),
+			check: func(dest interface{}) bool {
+				ranks := dest.(*LocationRanks).Rows
+				return len(ranks) == 1 && ranks[0].Place == "Cafe" && ranks[0].Avg == 5 && ranks[0].Visits == 1
+			},
+		},
+		{
+			url:    fmt.Sprintf("/locations/%d/stats?asOf=%d", *location.ID, asOf),
+			status: http.StatusBadRequest,
+		},
+		{
+			url:    fmt.Sprintf("/users/%d/avg?asOf=%d", *user.ID, asOf),
+			status: http.StatusBadRequest,
+		},
+	}
+	for _, test := range tests {
+		w := serve(a, http.MethodGet, test.url, nil)
+		if w.Code != test.status {
+			t.Errorf("GET %s responded with %d, want %d: %s", test.url, w.Code, test.status, w.Body)
+			continue
+		}
+		if test.dest == nil {
+			continue
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), test.dest); err != nil {
+			t.Errorf("GET %s: %v", test.url, err)
+			continue
+		}
+		if !test.check(test.dest) {
+			t.Errorf("GET %s responded with unexpected %s", test.url, w.Body)
+		}
+	}
+}
+
+// TestLoadedHistory checks that rows loaded on startup are not recorded to
+// history, but are still restored as of times after loading.
+func TestLoadedHistory(t *testing.T) {
+	a := testApp(t)
+	d := a.Database
+
+	user := testUser("loaded@example.com")
+	user.ID = new(uint32)
+	*user.ID = 1
+	if err := d.PopulateUsers(&Users{Rows: []*User{user}}); err != nil {
+		t.Fatal(err)
+	}
+
+	url := fmt.Sprintf("/users/%d/history", *user.ID)
+	if w := serve(a, http.MethodGet, url, nil); w.Code != http.StatusNotFound {
+		t.Errorf("GET %s responded with %d, want %d: %s", url, w.Code, http.StatusNotFound, w.Body)
+	}
+
+	// The user is loaded an hour ago, moving it back is not recorded either.
+	tx := d.Socket.MustBegin()
+	tx.MustExec("SELECT set_config('app.loading', 'on', true)")
+	tx.MustExec("UPDATE users SET updated_at = updated_at - interval '1 hour'")
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now().Add(-2 * time.Hour).Unix()
+	asOf := time.Now().Add(-30 * time.Minute).Unix()
+
+	firstName := "Changed"
+	if err := d.UpdateUser(fmt.Sprint(*user.ID), &User{FirstName: &firstName}); err != nil {
+		t.Fatal(err)
+	}
+
+	history := new(History)
+	if w := serve(a, http.MethodGet, url, nil); w.Code != http.StatusOK {
+		t.Errorf("GET %s responded with %d, want %d: %s", url, w.Code, http.StatusOK, w.Body)
+	} else if err := json.Unmarshal(w.Body.Bytes(), history); err != nil {
+		t.Errorf("GET %s: %v", url, err)
+	} else if len(history.Rows) != 1 || history.Rows[0].Operation != "update" {
+		t.Errorf("GET %s responded with unexpected %s", url, w.Body)
+	}
+
+	url = fmt.Sprintf("/users/%d?asOf=%d", *user.ID, asOf)
+	restored := new(User)
+	if w := serve(a, http.MethodGet, url, nil); w.Code != http.StatusOK {
+		t.Errorf("GET %s responded with %d, want %d: %s", url, w.Code, http.StatusOK, w.Body)
+	} else if err := json.Unmarshal(w.Body.Bytes(), restored); err != nil {
+		t.Errorf("GET %s: %v", url, err)
+	} else if *restored.FirstName != "Test" {
+		t.Errorf("GET %s responded with unexpected %s", url, w.Body)
+	}
+
+	url = fmt.Sprintf("/users/%d?asOf=%d", *user.ID, before)
+	if w := serve(a, http.MethodGet, url, nil); w.Code != http.StatusNotFound {
+		t.Errorf("GET %s responded with %d, want %d: %s", url, w.Code, http.StatusNotFound, w.Body)
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	// maxIdempotentBodySize bounds bodies of requests with idempotency key,
+	// which are read into memory to fingerprint them.
+	maxIdempotentBodySize = 1 << 20
+)
+
+// recordedResponse contains response written by the handler.
+type recordedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recordedResponse) Header() http.Header {
+	return r.header
+}
+
+func (r *recordedResponse) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
+
+func (r *recordedResponse) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+// replay writes recorded response to w.
+func (r *recordedResponse) replay(w http.ResponseWriter) {
+	for key, values := range r.header {
+		w.Header()[key] = values
+	}
+	if r.status != 0 {
+		w.WriteHeader(r.status)
+	}
+	if _, err := w.Write(r.body.Bytes()); err != nil {
+		log.Println(err)
+	}
+}
+
+// idempotencyEntry contains outcome of the request with idempotency key.
+// Done is closed once the response is recorded.
+type idempotencyEntry struct {
+	key         string
+	fingerprint [sha256.Size]byte
+	expires     time.Time
+	done        chan struct{}
+	response    *recordedResponse
+	element     *list.Element
+}
+
+// idempotencyStore keeps bounded number of request outcomes by their
+// idempotency keys for the ttl, evicting the oldest completed ones when full.
+type idempotencyStore struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	capacity int
+	entries  map[string]*idempotencyEntry
+	order    *list.List
+}
+
+func newIdempotencyStore(ttl time.Duration, capacity int) *idempotencyStore {
+	return &idempotencyStore{
+		ttl:      ttl,
+		capacity: capacity,
+		entries:  make(map[string]*idempotencyEntry),
+		order:    list.New(),
+	}
+}
+
+// completed reports whether response of the entry is recorded.
+func (e *idempotencyEntry) completed() bool {
+	select {
+	case <-e.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *idempotencyStore) remove(entry *idempotencyEntry) {
+	delete(s.entries, entry.key)
+	s.order.Remove(entry.element)
+}
+
+// acquire returns entry of the key and whether it has been created by this call.
+func (s *idempotencyStore) acquire(key string, fingerprint [sha256.Size]byte) (*idempotencyEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if entry, ok := s.entries[key]; ok {
+		if now.Before(entry.expires) || !entry.completed() {
+			return entry, false
+		}
+		s.remove(entry)
+	}
+
+	// Entries are ordered by expiration, expired ones are purged first.
+	for element := s.order.Front(); element != nil; {
+		entry := element.Value.(*idempotencyEntry)
+		if now.Before(entry.expires) {
+			break
+		}
+		element = element.Next()
+		if entry.completed() {
+			s.remove(entry)
+		}
+	}
+
+	// Entries of requests in progress are not evicted, otherwise their
+	// retries would execute the request again.
+	for element := s.order.Front(); element != nil && s.order.Len() >= s.capacity; {
+		entry := element.Value.(*idempotencyEntry)
+		element = element.Next()
+		if entry.completed() {
+			s.remove(entry)
+		}
+	}
+
+	entry := &idempotencyEntry{
+		key:         key,
+		fingerprint: fingerprint,
+		expires:     now.Add(s.ttl),
+		done:        make(chan struct{}),
+	}
+	entry.element = s.order.PushBack(entry)
+	s.entries[key] = entry
+	return entry, true
+}
+
+// complete records response of the entry. Server errors are not kept,
+// so that retries execute the request again.
+func (s *idempotencyStore) complete(entry *idempotencyEntry, response *recordedResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry.response = response
+	close(entry.done)
+	if response.status >= http.StatusInternalServerError && s.entries[entry.key] == entry {
+		s.remove(entry)
+	}
+}
+
+// wrap returns handler which executes requests with the same idempotency key
+// only once and replays the first outcome on their retries. Requests failed
+// with server error are executed again on retry.
+func (s *idempotencyStore) wrap(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			h(w, r)
+			return
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodySize))
+		if err != nil {
+			log.Println(err)
+			status := http.StatusBadRequest
+			if _, ok := err.(*http.MaxBytesError); ok {
+				status = http.StatusRequestEntityTooLarge
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		fingerprint := sha256.Sum256(body)
+		for {
+			entry, created := s.acquire(r.URL.Path+" "+key, fingerprint)
+			if created {
+				response := &recordedResponse{header: make(http.Header)}
+				defer func() {
+					s.complete(entry, response)
+					response.replay(w)
+				}()
+				h(response, r)
+				return
+			}
+
+			if entry.fingerprint != fingerprint {
+				http.Error(w, "idempotency key reused with different request", http.StatusUnprocessableEntity)
+				return
+			}
+			<-entry.done
+			if entry.response.status < http.StatusInternalServerError {
+				entry.response.replay(w)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// postIdempotent sends request with the idempotency key and returns response status and body.
+func postIdempotent(t *testing.T, url, key, body string) (int, string) {
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Error(err)
+		return 0, ""
+	}
+	req.Header.Set(idempotencyKeyHeader, key)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Error(err)
+		return 0, ""
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+	return resp.StatusCode, string(b)
+}
+
+func TestIdempotencyConcurrentRequests(t *testing.T) {
+	const requests = 20
+
+	var inserts int32
+	store := newIdempotencyStore(time.Minute, requests)
+	server := httptest.NewServer(store.wrap(func(w http.ResponseWriter, r *http.Request) {
+		id := atomic.AddInt32(&inserts, 1)
+		time.Sleep(50 * time.Millisecond)
+		fmt.Fprintf(w, `{"id":%d}`, id)
+	}))
+	defer server.Close()
+
+	var wg sync.WaitGroup
+	statuses := make([]int, requests)
+	bodies := make([]string, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			statuses[i], bodies[i] = postIdempotent(t, server.URL, "key", `{"email":"a@b.c"}`)
+		}(i)
+	}
+	wg.Wait()
+
+	if inserts != 1 {
+		t.Errorf("request executed %d times, want once", inserts)
+	}
+	for i := range bodies {
+		if statuses[i] != http.StatusOK || bodies[i] != bodies[0] {
+			t.Errorf("response %d is %d %q, want %d %q", i, statuses[i], bodies[i], http.StatusOK, bodies[0])
+		}
+	}
+}
+
+func TestIdempotencyServerErrorNotKept(t *testing.T) {
+	var calls int32
+	store := newIdempotencyStore(time.Minute, 10)
+	server := httptest.NewServer(store.wrap(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			http.Error(w, "database is unavailable", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, "{}")
+	}))
+	defer server.Close()
+
+	if status, _ := postIdempotent(t, server.URL, "key", "{}"); status != http.StatusInternalServerError {
+		t.Fatalf("first response status is %d, want %d", status, http.StatusInternalServerError)
+	}
+	if status, _ := postIdempotent(t, server.URL, "key", "{}"); status != http.StatusOK {
+		t.Errorf("retry response status is %d, want %d", status, http.StatusOK)
+	}
+	if calls != 2 {
+		t.Errorf("request executed %d times, want twice", calls)
+	}
+}
+
+func TestIdempotencyInProgressNotEvicted(t *testing.T) {
+	store := newIdempotencyStore(time.Minute, 1)
+
+	entry, created := store.acquire("first", [sha256.Size]byte{})
+	if !created {
+		t.Fatal("entry of the first key is not created")
+	}
+	if _, created := store.acquire("second", [sha256.Size]byte{}); !created {
+		t.Fatal("entry of the second key is not created")
+	}
+	if got, created := store.acquire("first", [sha256.Size]byte{}); created || got != entry {
+		t.Error("entry of request in progress is evicted")
+	}
+
+	store.complete(entry, &recordedResponse{status: http.StatusOK})
+	if _, created := store.acquire("third", [sha256.Size]byte{}); !created {
+		t.Fatal("entry of the third key is not created")
+	}
+	if _, ok := store.entries["first"]; ok {
+		t.Error("completed entry is not evicted")
+	}
+}
+
+func TestIdempotencyExpiredPurged(t *testing.T) {
+	store := newIdempotencyStore(10*time.Millisecond, 10)
+
+	entry, _ := store.acquire("first", [sha256.Size]byte{})
+	store.complete(entry, &recordedResponse{status: http.StatusOK})
+	time.Sleep(20 * time.Millisecond)
+
+	if _, created := store.acquire("second", [sha256.Size]byte{}); !created {
+		t.Fatal("entry of the second key is not created")
+	}
+	if _, ok := store.entries["first"]; ok {
+		t.Error("expired entry is not purged")
+	}
+}
+
+func TestIdempotencyBodyTooLarge(t *testing.T) {
+	calls := 0
+	store := newIdempotencyStore(time.Minute, 10)
+	server := httptest.NewServer(store.wrap(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer server.Close()
+
+	status, _ := postIdempotent(t, server.URL, "key", strings.Repeat("x", maxIdempotentBodySize+1))
+	if status != http.StatusRequestEntityTooLarge {
+		t.Errorf("responded with %d, want %d", status, http.StatusRequestEntityTooLarge)
+	}
+	if calls != 0 {
+		t.Errorf("request executed %d times, want never", calls)
+	}
+}
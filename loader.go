@@ -2,6 +2,9 @@ package main
 
 import (
 	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"io"
 	"io/ioutil"
@@ -20,6 +23,7 @@ func LoadData(archive string, d *Database) error {
 
 	for _, file := range zipReader.File {
 		name := file.Name[:strings.LastIndex(file.Name, ".")]
+		ext := file.Name[len(name):]
 
 		if name == "options" {
 			f, err := file.Open()
@@ -47,7 +51,15 @@ func LoadData(archive string, d *Database) error {
 		if err != nil {
 			return err
 		}
-		if err = loadEntity(entity, &reader, d); err != nil {
+		switch ext {
+		case ".csv":
+			err = loadCSV(file.Name, entity, &reader, d)
+		case ".ndjson":
+			err = loadNDJSON(file.Name, entity, &reader, d)
+		default:
+			err = loadEntity(entity, &reader, d)
+		}
+		if err != nil {
 			return err
 		}
 
@@ -114,3 +126,93 @@ func loadVisits(r *io.ReadCloser, d *Database) error {
 	}
 	return d.PopulateVisits(visits)
 }
+
+// newEntityRow returns constructor of the entity rows or nil for unknown entity.
+func newEntityRow(entity string) func() interface{} {
+	switch entity {
+	case "users":
+		return func() interface{} { return new(User) }
+	case "locations":
+		return func() interface{} { return new(Location) }
+	case "visits":
+		return func() interface{} { return new(Visit) }
+	}
+	return nil
+}
+
+// loadCSV loads entity rows from CSV file with header naming row fields.
+// Lines failed to parse are logged and skipped.
+func loadCSV(file string, entity string, r *io.ReadCloser, d *Database) error {
+	newRow := newEntityRow(entity)
+	if newRow == nil {
+		return nil
+	}
+
+	reader := csv.NewReader(*r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return err
+	}
+	fields, err := csvFields(newRow(), header)
+	if err != nil {
+		return err
+	}
+
+	rows := []interface{}{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if _, ok := err.(*csv.ParseError); ok {
+				log.Printf("%s: %v", file, err)
+				continue
+			}
+			return err
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := newRow()
+		if err := setCSVRecord(row, fields, record); err != nil {
+			log.Printf("%s:%d: %v", file, line, err)
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	return d.populate(rows)
+}
+
+// loadNDJSON loads entity rows from newline-delimited JSON file.
+// Lines failed to parse are logged and skipped.
+func loadNDJSON(file string, entity string, r *io.ReadCloser, d *Database) error {
+	newRow := newEntityRow(entity)
+	if newRow == nil {
+		return nil
+	}
+
+	rows := []interface{}{}
+	scanner := bufio.NewScanner(*r)
+	scanner.Buffer(make([]byte, bufio.MaxScanTokenSize), maxBulkLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		row := newRow()
+		if err := json.Unmarshal(data, row); err != nil {
+			log.Printf("%s:%d: %v", file, line, err)
+			continue
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return d.populate(rows)
+}
@@ -1,17 +1,35 @@
 package main
 
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
 // Location contains location database record.
 type Location struct {
-	ID       *uint32 `json:"id" db:"id"`
-	Place    *string `json:"place" db:"place"`
-	Country  *string `json:"country" db:"country"`
-	City     *string `json:"city" db:"city"`
-	Distance *uint32 `json:"distance" db:"distance"`
+	ID        *uint32    `json:"id" db:"id"`
+	Place     *string    `json:"place" db:"place"`
+	Country   *string    `json:"country" db:"country"`
+	City      *string    `json:"city" db:"city"`
+	Distance  *uint32    `json:"distance" db:"distance"`
+	Version   *uint64    `json:"version,omitempty" db:"version"`
+	UpdatedAt *time.Time `json:"-" db:"updated_at"`
 }
 
 // Locations contains slice of locations.
 type Locations struct {
 	Rows []*Location `json:"locations"`
+	Next *uint32     `json:"next,omitempty"`
+}
+
+// LocationListFilter contains locations listing parameters from requests.
+type LocationListFilter struct {
+	Page
+	Country      *string `schema:"country"`
+	City         *string `schema:"city"`
+	FromDistance *uint32 `schema:"fromDistance"`
+	ToDistance   *uint32 `schema:"toDistance"`
 }
 
 // LocationFilter contains locations filtering parameters from requests.
@@ -23,7 +41,78 @@ type LocationFilter struct {
 	Gender   *string `schema:"gender"`
 }
 
+// LocationAvgFilter contains parameters of location average mark from requests,
+// which can be answered as of the past time.
+type LocationAvgFilter struct {
+	LocationFilter
+	AsOfFilter
+}
+
+// LocationSearchFilter contains locations search parameters from requests.
+type LocationSearchFilter struct {
+	Query *string `schema:"q"`
+	Limit *uint64 `schema:"limit"`
+}
+
+// GetLimit returns number of found locations bounded by maxPageLimit.
+func (f *LocationSearchFilter) GetLimit() uint64 {
+	if f.Limit == nil || *f.Limit == 0 {
+		return defaultPageLimit
+	}
+	if *f.Limit > maxPageLimit {
+		return maxPageLimit
+	}
+	return *f.Limit
+}
+
+// LocationTopFilter contains locations ranking parameters from requests.
+type LocationTopFilter struct {
+	LocationFilter
+	AsOfFilter
+	Country   *string `schema:"country"`
+	City      *string `schema:"city"`
+	MinVisits *uint64 `schema:"minVisits"`
+	Limit     *uint64 `schema:"limit"`
+}
+
+// GetLimit returns ranking size bounded by maxPageLimit.
+func (f *LocationTopFilter) GetLimit() uint64 {
+	if f.Limit == nil || *f.Limit == 0 {
+		return defaultTopLimit
+	}
+	if *f.Limit > maxPageLimit {
+		return maxPageLimit
+	}
+	return *f.Limit
+}
+
+// LocationRank contains location with its average mark.
+type LocationRank struct {
+	ID      uint32  `json:"id" db:"id"`
+	Place   string  `json:"place" db:"place"`
+	Country string  `json:"country" db:"country"`
+	City    string  `json:"city" db:"city"`
+	Avg     float64 `json:"avg" db:"avg"`
+	Visits  int64   `json:"visits" db:"visits"`
+}
+
+// LocationRanks contains slice of ranked locations.
+type LocationRanks struct {
+	Rows []*LocationRank `json:"locations"`
+}
+
 // LocationAvgMark contains location average mark.
 type LocationAvgMark struct {
 	Avg float64 `json:"avg" db:"avg"`
 }
+
+// LocationStats contains location visits statistics.
+type LocationStats struct {
+	Count        int64         `json:"count" db:"count"`
+	Avg          float64       `json:"avg" db:"avg"`
+	Histogram    pq.Int64Array `json:"histogram" db:"histogram"`
+	Median       float64       `json:"median" db:"median"`
+	StdDev       float64       `json:"stddev" db:"stddev"`
+	MinVisitedAt *int32        `json:"min_visited_at" db:"min_visited_at"`
+	MaxVisitedAt *int32        `json:"max_visited_at" db:"max_visited_at"`
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// TestTopLocationsUnrated checks that locations without marks are not ranked
+// neither from the aggregate nor from filtered visits.
+func TestTopLocationsUnrated(t *testing.T) {
+	a := testApp(t)
+	d := a.Database
+
+	user := testUser("user@example.com")
+	if err := d.InsertUser(user); err != nil {
+		t.Fatal(err)
+	}
+	rated, unrated := testLocation("Rated"), testLocation("Unrated")
+	for _, location := range []*Location{rated, unrated} {
+		if err := d.InsertLocation(location); err != nil {
+			t.Fatal(err)
+		}
+	}
+	unratedVisit := testVisit(unrated.ID, user.ID, 0)
+	unratedVisit.Mark = nil
+	for _, visit := range []*Visit{testVisit(rated.ID, user.ID, 3), unratedVisit} {
+		if err := d.InsertVisit(visit); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, url := range []string{"/locations/top", "/locations/top?gender=f"} {
+		w := serve(a, http.MethodGet, url, nil)
+		if w.Code != http.StatusOK {
+			t.Errorf("GET %s responded with %d: %s", url, w.Code, w.Body)
+			continue
+		}
+
+		ranks := new(LocationRanks)
+		if err := json.Unmarshal(w.Body.Bytes(), ranks); err != nil {
+			t.Errorf("GET %s: %v", url, err)
+			continue
+		}
+		if len(ranks.Rows) != 1 || ranks.Rows[0].Place != "Rated" || ranks.Rows[0].Avg != 3 {
+			t.Errorf("GET %s responded with %s, want only rated location", url, w.Body)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// TestAPIOperations checks that every route is described in the OpenAPI
+// document and every described operation is routed.
+func TestAPIOperations(t *testing.T) {
+	a := &App{
+		Router:      mux.NewRouter(),
+		idempotency: newIdempotencyStore(0, 0),
+	}
+	a.initializeRoutes()
+
+	routed := make(map[string]bool)
+	err := a.Router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return err
+		}
+
+		for _, method := range methods {
+			routed[method+" "+openAPIPath(template)] = true
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	documented := make(map[string]bool, len(apiOperations))
+	for _, o := range apiOperations {
+		operation := o.method + " " + o.path
+		if documented[operation] {
+			t.Errorf("operation %s is described twice", operation)
+		}
+		documented[operation] = true
+	}
+
+	for _, operation := range sortedKeys(routed) {
+		if !documented[operation] {
+			t.Errorf("route %s is missing from apiOperations", operation)
+		}
+	}
+	for _, operation := range sortedKeys(documented) {
+		if !routed[operation] {
+			t.Errorf("operation %s is not routed", operation)
+		}
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
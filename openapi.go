@@ -0,0 +1,275 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Content types of non-JSON request and response bodies.
+const (
+	contentTypeNDJSON      = "application/x-ndjson"
+	contentTypeCSV         = "text/csv"
+	contentTypeEventStream = "text/event-stream"
+)
+
+// apiOperation describes route operation in the OpenAPI document.
+// Nil response means empty JSON object.
+type apiOperation struct {
+	method       string
+	path         string
+	summary      string
+	query        interface{}
+	body         interface{}
+	bodyType     string
+	response     interface{}
+	responseType []string
+}
+
+// apiOperations describes every route registered by initializeRoutes,
+// main_test.go checks that they match.
+var apiOperations = []apiOperation{
+	{method: "GET", path: "/users", summary: "List users", query: new(UserListFilter), response: new(Users)},
+	{method: "GET", path: "/users/{id}", summary: "Get user", query: new(AsOfFilter), response: new(User)},
+	{method: "GET", path: "/users/by-email/{email}", summary: "Get user by email", query: new(AsOfFilter), response: new(User)},
+	{method: "GET", path: "/users/search", summary: "Search users by name prefix", query: new(UserSearchFilter), response: new(Users)},
+	{method: "GET", path: "/users/{id}/visits", summary: "List places visited by user", query: new(UserVisitsFilter), response: new(Places)},
+	{method: "GET", path: "/users/{id}/avg", summary: "Get average mark of user", query: new(PlaceFilter), response: new(UserAvgMark)},
+	{method: "GET", path: "/users/{id}/stats", summary: "Get visits statistics of user", query: new(PlaceFilter), response: new(UserStats)},
+	{method: "GET", path: "/users/{id}/recommendations", summary: "Recommend locations to user", query: new(RecommendationFilter), response: new(Recommendations)},
+	{method: "GET", path: "/users/{id}/history", summary: "Get change history of user", response: new(History)},
+	{method: "POST", path: "/users/{id}", summary: "Update user", body: new(User)},
+	{method: "POST", path: "/users/new", summary: "Create user", body: new(User), response: new(createdJSON)},
+	{method: "POST", path: "/users/bulk", summary: "Create users in bulk", body: new(User), bodyType: contentTypeNDJSON, response: new(BulkResult)},
+	{method: "DELETE", path: "/users/{id}", summary: "Delete user"},
+
+	{method: "GET", path: "/locations", summary: "List locations", query: new(LocationListFilter), response: new(Locations)},
+	{method: "GET", path: "/locations/top", summary: "List top rated locations", query: new(LocationTopFilter), response: new(LocationRanks)},
+	{method: "GET", path: "/locations/search", summary: "Search locations", query: new(LocationSearchFilter), response: new(Locations)},
+	{method: "GET", path: "/locations/{id}", summary: "Get location", query: new(AsOfFilter), response: new(Location)},
+	{method: "GET", path: "/locations/{id}/avg", summary: "Get average mark of location", query: new(LocationAvgFilter), response: new(LocationAvgMark)},
+	{method: "GET", path: "/locations/{id}/visits", summary: "List visits of location", query: new(LocationFilter), response: new(Visitors)},
+	{method: "GET", path: "/locations/{id}/stats", summary: "Get visits statistics of location", query: new(LocationFilter), response: new(LocationStats)},
+	{method: "GET", path: "/locations/{id}/timeline", summary: "Get visits timeline of location", query: new(TimelineFilter), response: new(Timeline)},
+	{method: "GET", path: "/locations/{id}/related", summary: "List locations visited along with location", query: new(RecommendationFilter), response: new(Recommendations)},
+	{method: "GET", path: "/locations/{id}/history", summary: "Get change history of location", response: new(History)},
+	{method: "POST", path: "/locations/{id}", summary: "Update location", body: new(Location)},
+	{method: "POST", path: "/locations/new", summary: "Create location", body: new(Location), response: new(createdJSON)},
+	{method: "POST", path: "/locations/bulk", summary: "Create locations in bulk", body: new(Location), bodyType: contentTypeNDJSON, response: new(BulkResult)},
+	{method: "DELETE", path: "/locations/{id}", summary: "Delete location"},
+
+	{method: "GET", path: "/countries", summary: "List countries", response: new(Countries)},
+	{method: "GET", path: "/countries/{country}/avg", summary: "Get average mark of country", query: new(LocationFilter), response: new(LocationAvgMark)},
+	{method: "GET", path: "/cities/{city}/avg", summary: "Get average mark of city", query: new(LocationFilter), response: new(LocationAvgMark)},
+
+	{method: "POST", path: "/batch", summary: "Apply batch of operations", body: new(Batch), response: new(BatchResults)},
+	{method: "GET", path: "/export/{entity}", summary: "Export users, locations or visits matching list filters", query: new(exportQuery), responseType: []string{contentTypeNDJSON, contentTypeCSV}},
+	{method: "GET", path: "/changes", summary: "Stream entity changes as server-sent events", response: new(ChangeEvent), responseType: []string{contentTypeEventStream}},
+
+	{method: "GET", path: "/admin/webhooks", summary: "List webhooks", response: new(Webhooks)},
+	{method: "POST", path: "/admin/webhooks", summary: "Create webhook", body: new(Webhook), response: new(createdJSON)},
+	{method: "DELETE", path: "/admin/webhooks/{id}", summary: "Delete webhook"},
+
+	{method: "GET", path: "/visits", summary: "List visits", query: new(VisitListFilter), response: new(Visits)},
+	{method: "GET", path: "/visits/{id}", summary: "Get visit", query: new(AsOfFilter), response: new(Visit)},
+	{method: "GET", path: "/visits/{id}/history", summary: "Get change history of visit", response: new(History)},
+	{method: "POST", path: "/visits/{id}", summary: "Update visit", body: new(Visit)},
+	{method: "POST", path: "/visits/new", summary: "Create visit", body: new(Visit), response: new(createdJSON)},
+	{method: "POST", path: "/visits/bulk", summary: "Create visits in bulk", body: new(Visit), bodyType: contentTypeNDJSON, response: new(BulkResult)},
+	{method: "DELETE", path: "/visits/{id}", summary: "Delete visit"},
+
+	{method: "GET", path: "/openapi.json", summary: "Get OpenAPI document"},
+}
+
+// exportQuery contains export parameters, rows are filtered and paged by
+// parameters of the exported entities list.
+type exportQuery struct {
+	Format *string `schema:"format"`
+}
+
+// pathVariable matches mux route variable with optional pattern.
+var pathVariable = regexp.MustCompile(`\{(\w+)(:[^}]*)?\}`)
+
+// openAPIPath returns OpenAPI path template of mux route template.
+func openAPIPath(template string) string {
+	return pathVariable.ReplaceAllString(template, "{$1}")
+}
+
+// openAPISchemas collects schemas of named types referenced by the document.
+type openAPISchemas map[string]interface{}
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	rawType   = reflect.TypeOf(json.RawMessage{})
+	jsonbType = reflect.TypeOf(JSONB{})
+)
+
+// schema returns schema of the type, schemas of named structs are
+// collected and referenced.
+func (s openAPISchemas) schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case rawType, jsonbType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": s.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.schema(t.Elem())}
+	case reflect.Struct:
+		name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+		if _, ok := s[name]; !ok {
+			s[name] = nil
+			s[name] = map[string]interface{}{"type": "object", "properties": s.properties(t)}
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	panic(fmt.Sprintf("unsupported type %s", t))
+}
+
+// properties returns schemas of the struct fields serialized to JSON.
+func (s openAPISchemas) properties(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.Anonymous && name == "" {
+			for name, property := range s.properties(field.Type) {
+				properties[name] = property
+			}
+			continue
+		}
+		if name == "-" || field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = s.schema(field.Type)
+	}
+	return properties
+}
+
+// parameters returns query parameters of the filter decoded from query.
+func (s openAPISchemas) parameters(t reflect.Type) []interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var parameters []interface{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			parameters = append(parameters, s.parameters(field.Type)...)
+			continue
+		}
+		name := strings.Split(field.Tag.Get("schema"), ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+		parameters = append(parameters, map[string]interface{}{
+			"name":   name,
+			"in":     "query",
+			"schema": s.schema(field.Type),
+		})
+	}
+	return parameters
+}
+
+// operation returns OpenAPI operation object.
+func (s openAPISchemas) operation(o apiOperation) map[string]interface{} {
+	var parameters []interface{}
+	for _, match := range pathVariable.FindAllStringSubmatch(o.path, -1) {
+		schema := map[string]interface{}{"type": "string"}
+		if match[1] == "id" {
+			schema = map[string]interface{}{"type": "integer", "format": "int32"}
+		}
+		parameters = append(parameters, map[string]interface{}{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   schema,
+		})
+	}
+	if o.query != nil {
+		parameters = append(parameters, s.parameters(reflect.TypeOf(o.query))...)
+	}
+
+	operation := map[string]interface{}{"summary": o.summary}
+	if parameters != nil {
+		operation["parameters"] = parameters
+	}
+
+	if o.body != nil {
+		bodyType := o.bodyType
+		if bodyType == "" {
+			bodyType = "application/json"
+		}
+		operation["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				bodyType: map[string]interface{}{"schema": s.schema(reflect.TypeOf(o.body))},
+			},
+		}
+	}
+
+	schema := map[string]interface{}{"type": "object"}
+	if o.response != nil {
+		schema = s.schema(reflect.TypeOf(o.response))
+	}
+	responseTypes := o.responseType
+	if responseTypes == nil {
+		responseTypes = []string{"application/json"}
+	}
+	content := make(map[string]interface{})
+	for _, responseType := range responseTypes {
+		content[responseType] = map[string]interface{}{"schema": schema}
+	}
+	operation["responses"] = map[string]interface{}{
+		"200": map[string]interface{}{"description": "OK", "content": content},
+	}
+
+	return operation
+}
+
+// openAPIDocument returns OpenAPI document describing apiOperations.
+func openAPIDocument() ([]byte, error) {
+	schemas := make(openAPISchemas)
+	paths := make(map[string]map[string]interface{})
+	for _, o := range apiOperations {
+		if paths[o.path] == nil {
+			paths[o.path] = make(map[string]interface{})
+		}
+		paths[o.path][strings.ToLower(o.method)] = schemas.operation(o)
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"openapi": "3.0.0",
+		"info": map[string]interface{}{
+			"title":   "HighLoad Cup 2017",
+			"version": "1.0.0",
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": schemas},
+	})
+}
@@ -0,0 +1,34 @@
+package main
+
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+	defaultTopLimit  = 10
+)
+
+// Page contains cursor-based pagination parameters from requests.
+// Rows are ordered by id and the page starts right after the After id.
+type Page struct {
+	After *uint32 `schema:"after"`
+	Limit *uint64 `schema:"limit"`
+}
+
+// GetLimit returns page size bounded by maxPageLimit.
+func (p *Page) GetLimit() uint64 {
+	if p.Limit == nil || *p.Limit == 0 {
+		return defaultPageLimit
+	}
+	if *p.Limit > maxPageLimit {
+		return maxPageLimit
+	}
+	return *p.Limit
+}
+
+// nextCursor returns cursor of the page following the one ended by last id,
+// or nil when the page of specified size is the last one.
+func (p *Page) nextCursor(size int, last *uint32) *uint32 {
+	if uint64(size) < p.GetLimit() {
+		return nil
+	}
+	return last
+}
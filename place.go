@@ -19,3 +19,10 @@ type PlaceFilter struct {
 	Country  *string `schema:"country"`
 	Distance *uint32 `schema:"distance"`
 }
+
+// UserVisitsFilter contains parameters of user's visits listing from requests,
+// which can be answered as of the past time.
+type UserVisitsFilter struct {
+	PlaceFilter
+	AsOfFilter
+}
@@ -0,0 +1,56 @@
+package main
+
+const (
+	defaultRecommendationMinSupport = 1
+	defaultRecommendationMinAvg     = 3
+)
+
+// RecommendationFilter contains recommendations parameters from requests.
+type RecommendationFilter struct {
+	MinSupport *uint64  `schema:"minSupport"`
+	MinAvg     *float64 `schema:"minAvg"`
+	Limit      *uint64  `schema:"limit"`
+}
+
+// GetMinSupport returns minimal number of users who co-visited recommended location.
+func (f *RecommendationFilter) GetMinSupport() uint64 {
+	if f.MinSupport == nil {
+		return defaultRecommendationMinSupport
+	}
+	return *f.MinSupport
+}
+
+// GetMinAvg returns minimal average mark of recommended location.
+func (f *RecommendationFilter) GetMinAvg() float64 {
+	if f.MinAvg == nil {
+		return defaultRecommendationMinAvg
+	}
+	return *f.MinAvg
+}
+
+// GetLimit returns number of recommendations bounded by maxPageLimit.
+func (f *RecommendationFilter) GetLimit() uint64 {
+	if f.Limit == nil || *f.Limit == 0 {
+		return defaultTopLimit
+	}
+	if *f.Limit > maxPageLimit {
+		return maxPageLimit
+	}
+	return *f.Limit
+}
+
+// Recommendation contains recommended location with number of users
+// supporting the recommendation and location average mark.
+type Recommendation struct {
+	ID      uint32  `json:"id" db:"id"`
+	Place   string  `json:"place" db:"place"`
+	Country string  `json:"country" db:"country"`
+	City    string  `json:"city" db:"city"`
+	Support int64   `json:"support" db:"support"`
+	Avg     float64 `json:"avg" db:"avg"`
+}
+
+// Recommendations contains slice of recommendations.
+type Recommendations struct {
+	Rows []*Recommendation `json:"locations"`
+}
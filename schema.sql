@@ -1,29 +1,273 @@
-DROP TABLE IF EXISTS users, locations, visits;
+DROP TABLE IF EXISTS users, locations, visits, co_visits, location_marks, history;
 DROP TYPE IF EXISTS gender;
 
 CREATE TYPE gender AS ENUM ('m', 'f');
 
 CREATE TABLE IF NOT EXISTS users (
-    id bigint PRIMARY KEY,
+    id bigserial PRIMARY KEY,
     email varchar(100) UNIQUE NOT NULL,
     first_name varchar(50) NOT NULL,
     last_name varchar(50) NOT NULL,
     gender gender,
-    birth_date bigint NOT NULL
+    birth_date bigint NOT NULL,
+    version bigint NOT NULL DEFAULT 1,
+    updated_at timestamptz NOT NULL DEFAULT now()
 );
 
 CREATE TABLE IF NOT EXISTS locations (
-    id bigint PRIMARY KEY,
+    id bigserial PRIMARY KEY,
     place text NOT NULL,
     country varchar(50) NOT NULL,
     city varchar(50) NOT NULL,
-    distance bigint NOT NULL
+    distance bigint NOT NULL,
+    version bigint NOT NULL DEFAULT 1,
+    updated_at timestamptz NOT NULL DEFAULT now()
 );
 
 CREATE TABLE IF NOT EXISTS visits (
-    id bigint PRIMARY KEY,
+    id bigserial PRIMARY KEY,
     location bigint REFERENCES locations NOT NULL,
     "user" bigint REFERENCES users NOT NULL,
     visited_at bigint NOT NULL,
-    mark integer CHECK (mark BETWEEN 0 AND 5)
+    mark integer CHECK (mark BETWEEN 0 AND 5),
+    version bigint NOT NULL DEFAULT 1,
+    updated_at timestamptz NOT NULL DEFAULT now()
 );
+
+CREATE INDEX IF NOT EXISTS users_first_name_idx ON users (lower(first_name) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS users_last_name_idx ON users (lower(last_name) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS visits_user_idx ON visits ("user", location);
+CREATE INDEX IF NOT EXISTS visits_location_idx ON visits (location) INCLUDE ("user", visited_at, mark);
+CREATE INDEX IF NOT EXISTS locations_country_idx ON locations (country);
+CREATE INDEX IF NOT EXISTS locations_city_idx ON locations (city);
+CREATE INDEX IF NOT EXISTS locations_search_idx ON locations
+    USING GIN (to_tsvector('simple', place || ' ' || city || ' ' || country));
+
+-- co_visits contains numbers of distinct users who visited both locations.
+-- It is maintained by the trigger on visits, so every pair is stored twice.
+CREATE TABLE IF NOT EXISTS co_visits (
+    location bigint NOT NULL,
+    related bigint NOT NULL,
+    users bigint NOT NULL,
+    PRIMARY KEY (location, related)
+);
+
+-- Changes of visits of the same user are serialized, otherwise concurrent
+-- transactions would not see visits of each other and miss their pairs.
+CREATE OR REPLACE FUNCTION co_visits_change(u bigint, l bigint, visit bigint, delta integer) RETURNS void AS $$
+BEGIN
+    PERFORM pg_advisory_xact_lock(u);
+
+    IF EXISTS (SELECT 1 FROM visits WHERE "user" = u AND location = l AND id <> visit) THEN
+        RETURN;
+    END IF;
+
+    INSERT INTO co_visits (location, related, users)
+    SELECT l, other.location, delta
+    FROM (SELECT DISTINCT location FROM visits WHERE "user" = u AND location <> l AND id <> visit) other
+    UNION ALL
+    SELECT other.location, l, delta
+    FROM (SELECT DISTINCT location FROM visits WHERE "user" = u AND location <> l AND id <> visit) other
+    ON CONFLICT (location, related) DO UPDATE SET users = co_visits.users + EXCLUDED.users;
+
+    DELETE FROM co_visits WHERE (location = l OR related = l) AND users <= 0;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Runs before every row change, so changes of previously processed rows
+-- of the same statement are already visible to co_visits_change.
+CREATE OR REPLACE FUNCTION co_visits_update() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        PERFORM co_visits_change(OLD."user", OLD.location, OLD.id, -1);
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        PERFORM co_visits_change(NEW."user", NEW.location, NEW.id, 1);
+        RETURN NEW;
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER visits_co_visits
+    BEFORE INSERT OR DELETE OR UPDATE OF location, "user" ON visits
+    FOR EACH ROW EXECUTE PROCEDURE co_visits_update();
+
+-- location_marks contains numbers of visits and marks of locations ranked
+-- by their average marks. It is maintained by the trigger on visits.
+-- Average mark is NULL when no visit of the location has mark.
+CREATE TABLE IF NOT EXISTS location_marks (
+    location bigint PRIMARY KEY,
+    visits bigint NOT NULL,
+    marked bigint NOT NULL,
+    marks bigint NOT NULL,
+    avg_mark numeric GENERATED ALWAYS AS (round(marks::numeric / NULLIF(marked, 0), 2)) STORED
+);
+
+CREATE INDEX IF NOT EXISTS location_marks_rank_idx ON location_marks (avg_mark DESC NULLS LAST, visits DESC, location);
+
+CREATE OR REPLACE FUNCTION location_marks_change(l bigint, mark integer, delta integer) RETURNS void AS $$
+BEGIN
+    INSERT INTO location_marks (location, visits, marked, marks)
+    VALUES (l, delta, CASE WHEN mark IS NULL THEN 0 ELSE delta END, COALESCE(mark, 0) * delta)
+    ON CONFLICT (location) DO UPDATE SET
+        visits = location_marks.visits + EXCLUDED.visits,
+        marked = location_marks.marked + EXCLUDED.marked,
+        marks = location_marks.marks + EXCLUDED.marks;
+
+    DELETE FROM location_marks WHERE location = l AND visits <= 0;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION location_marks_update() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        PERFORM location_marks_change(OLD.location, OLD.mark, -1);
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        PERFORM location_marks_change(NEW.location, NEW.mark, 1);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER visits_location_marks
+    AFTER INSERT OR DELETE OR UPDATE OF location, mark ON visits
+    FOR EACH ROW EXECUTE PROCEDURE location_marks_update();
+
+-- history contains append-only log of changes of users, locations and visits
+-- with old and new values of changed fields and the full state after change.
+-- Changes are attributed to the request id set by app.request_id setting.
+CREATE TABLE IF NOT EXISTS history (
+    seq bigserial PRIMARY KEY,
+    entity varchar(16) NOT NULL,
+    entity_id bigint NOT NULL,
+    operation varchar(8) NOT NULL,
+    changed_at timestamptz NOT NULL DEFAULT clock_timestamp(),
+    request_id text,
+    old jsonb NOT NULL,
+    new jsonb NOT NULL,
+    state jsonb
+);
+
+CREATE INDEX IF NOT EXISTS history_entity_idx ON history (entity, entity_id, changed_at);
+-- history_as_of_idx serves restoring of entities as they were at some time,
+-- which takes the latest change of every entity made before that time.
+CREATE INDEX IF NOT EXISTS history_as_of_idx ON history (entity, entity_id, seq DESC) INCLUDE (changed_at);
+
+CREATE OR REPLACE FUNCTION history_record() RETURNS trigger AS $$
+DECLARE
+    changed_id bigint;
+    old_row jsonb := '{}';
+    new_row jsonb := '{}';
+    new_state jsonb;
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        changed_id := OLD.id;
+        old_row := to_jsonb(OLD) - 'version' - 'updated_at';
+    END IF;
+    -- Rows loaded on startup have no history, their loaded state is recorded
+    -- on the first change to restore them as they were before it.
+    IF TG_OP IN ('UPDATE', 'DELETE') AND NOT EXISTS (
+        SELECT 1 FROM history WHERE entity = TG_TABLE_NAME AND entity_id = OLD.id
+    ) THEN
+        INSERT INTO history (entity, entity_id, operation, changed_at, old, new, state)
+        VALUES (TG_TABLE_NAME, OLD.id, 'load', OLD.updated_at, '{}', '{}', to_jsonb(OLD));
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        changed_id := NEW.id;
+        new_row := to_jsonb(NEW) - 'version' - 'updated_at';
+        new_state := to_jsonb(NEW);
+    END IF;
+
+    INSERT INTO history (entity, entity_id, operation, request_id, old, new, state)
+    VALUES (
+        TG_TABLE_NAME,
+        changed_id,
+        CASE TG_OP WHEN 'INSERT' THEN 'create' ELSE lower(TG_OP) END,
+        NULLIF(current_setting('app.request_id', true), ''),
+        (SELECT COALESCE(jsonb_object_agg(key, value), '{}') FROM jsonb_each(old_row) WHERE new_row -> key IS DISTINCT FROM value),
+        (SELECT COALESCE(jsonb_object_agg(key, value), '{}') FROM jsonb_each(new_row) WHERE old_row -> key IS DISTINCT FROM value),
+        new_state
+    );
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- history_lock serializes transactions changing entities from their first
+-- change until commit, so that sequence numbers of history are allocated in
+-- commit order and the change feed never skips changes committed later with
+-- lower sequence numbers. The lock is taken before any row is changed, so
+-- it cannot deadlock with row locks.
+CREATE OR REPLACE FUNCTION history_lock() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtext('history'), 0);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER users_history_lock
+    BEFORE INSERT OR UPDATE OR DELETE ON users
+    FOR EACH STATEMENT EXECUTE PROCEDURE history_lock();
+CREATE TRIGGER locations_history_lock
+    BEFORE INSERT OR UPDATE OR DELETE ON locations
+    FOR EACH STATEMENT EXECUTE PROCEDURE history_lock();
+CREATE TRIGGER visits_history_lock
+    BEFORE INSERT OR UPDATE OR DELETE ON visits
+    FOR EACH STATEMENT EXECUTE PROCEDURE history_lock();
+
+-- Changes are not recorded while data is loaded on startup, which is marked
+-- by app.loading setting.
+CREATE TRIGGER users_history
+    AFTER INSERT OR UPDATE OR DELETE ON users
+    FOR EACH ROW WHEN (current_setting('app.loading', true) IS DISTINCT FROM 'on') EXECUTE PROCEDURE history_record();
+CREATE TRIGGER locations_history
+    AFTER INSERT OR UPDATE OR DELETE ON locations
+    FOR EACH ROW WHEN (current_setting('app.loading', true) IS DISTINCT FROM 'on') EXECUTE PROCEDURE history_record();
+CREATE TRIGGER visits_history
+    AFTER INSERT OR UPDATE OR DELETE ON visits
+    FOR EACH ROW WHEN (current_setting('app.loading', true) IS DISTINCT FROM 'on') EXECUTE PROCEDURE history_record();
+
+-- webhooks contains subscriptions to creations and updates of entities,
+-- entities is NULL for subscriptions to changes of all entities.
+-- Unlike entities, webhooks and their pending deliveries persist across restarts.
+CREATE TABLE IF NOT EXISTS webhooks (
+    id bigserial PRIMARY KEY,
+    url text UNIQUE NOT NULL,
+    secret text NOT NULL DEFAULT '',
+    entities varchar(16)[]
+);
+
+-- webhook_deliveries is the queue of changes to be delivered to webhooks.
+-- Changes are enqueued in the same transaction they are recorded to history
+-- and are copied, since history is recreated on restart.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id bigserial PRIMARY KEY,
+    webhook bigint REFERENCES webhooks ON DELETE CASCADE NOT NULL,
+    seq bigint NOT NULL,
+    entity varchar(16) NOT NULL,
+    entity_id bigint NOT NULL,
+    operation varchar(8) NOT NULL,
+    new jsonb NOT NULL,
+    attempts integer NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz NOT NULL DEFAULT now(),
+    last_error text
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_next_attempt_idx ON webhook_deliveries (next_attempt_at);
+
+CREATE OR REPLACE FUNCTION webhook_enqueue() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO webhook_deliveries (webhook, seq, entity, entity_id, operation, new)
+    SELECT id, NEW.seq, NEW.entity, NEW.entity_id, NEW.operation, NEW.new
+    FROM webhooks WHERE entities IS NULL OR NEW.entity = ANY (entities);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Only changes made by requests are delivered, loading of data on start is not.
+DROP TRIGGER IF EXISTS history_webhooks ON history;
+CREATE TRIGGER history_webhooks
+    AFTER INSERT ON history
+    FOR EACH ROW WHEN (NEW.operation IN ('create', 'update') AND NEW.request_id IS NOT NULL)
+    EXECUTE PROCEDURE webhook_enqueue();
@@ -0,0 +1,49 @@
+package main
+
+// Timeline buckets supported by location timeline.
+var timelineBuckets = map[string]bool{
+	"day":   true,
+	"week":  true,
+	"month": true,
+}
+
+const (
+	defaultTimelineBucket   = "day"
+	defaultTimelineTimezone = "UTC"
+)
+
+// TimelineFilter contains location timeline parameters from requests.
+type TimelineFilter struct {
+	LocationFilter
+	Bucket   *string `schema:"bucket"`
+	Timezone *string `schema:"tz"`
+}
+
+// GetBucket returns timeline bucket, day by default.
+func (f *TimelineFilter) GetBucket() string {
+	if f.Bucket == nil {
+		return defaultTimelineBucket
+	}
+	return *f.Bucket
+}
+
+// GetTimezone returns timezone used for bucketing, UTC by default.
+func (f *TimelineFilter) GetTimezone() string {
+	if f.Timezone == nil {
+		return defaultTimelineTimezone
+	}
+	return *f.Timezone
+}
+
+// TimelineBucket contains number of visits and average mark within bucket
+// started at the specified timestamp.
+type TimelineBucket struct {
+	Start  int64   `json:"start" db:"start"`
+	Visits int64   `json:"visits" db:"visits"`
+	Avg    float64 `json:"avg" db:"avg"`
+}
+
+// Timeline contains slice of timeline buckets.
+type Timeline struct {
+	Rows []*TimelineBucket `json:"timeline"`
+}
@@ -1,16 +1,52 @@
 package main
 
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
 // User contains user database record.
 type User struct {
-	ID        *uint32 `json:"id" db:"id"`
-	Email     *string `json:"email" db:"email"`
-	FirstName *string `json:"first_name" db:"first_name"`
-	LastName  *string `json:"last_name" db:"last_name"`
-	Gender    *string `json:"gender" db:"gender"`
-	BirthDate *int32  `json:"birth_date" db:"birth_date"`
+	ID        *uint32    `json:"id" db:"id"`
+	Email     *string    `json:"email" db:"email"`
+	FirstName *string    `json:"first_name" db:"first_name"`
+	LastName  *string    `json:"last_name" db:"last_name"`
+	Gender    *string    `json:"gender" db:"gender"`
+	BirthDate *int32     `json:"birth_date" db:"birth_date"`
+	Version   *uint64    `json:"version,omitempty" db:"version"`
+	UpdatedAt *time.Time `json:"-" db:"updated_at"`
 }
 
 // Users contains slice of users.
 type Users struct {
 	Rows []*User `json:"users"`
+	Next *uint32 `json:"next,omitempty"`
+}
+
+// UserListFilter contains users filtering parameters from requests.
+type UserListFilter struct {
+	Page
+	Gender        *string `schema:"gender"`
+	FromBirthDate *int32  `schema:"fromBirthDate"`
+	ToBirthDate   *int32  `schema:"toBirthDate"`
+}
+
+// UserSearchFilter contains users search parameters from requests.
+type UserSearchFilter struct {
+	Page
+	Name *string `schema:"name"`
+}
+
+// UserAvgMark contains average mark given by user.
+type UserAvgMark struct {
+	Avg float64 `json:"avg" db:"avg"`
+}
+
+// UserStats contains statistics of marks given by user.
+type UserStats struct {
+	Count     int64         `json:"count" db:"count"`
+	Avg       float64       `json:"avg" db:"avg"`
+	Histogram pq.Int64Array `json:"histogram" db:"histogram"`
+	Countries int64         `json:"countries" db:"countries"`
 }
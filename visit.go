@@ -1,15 +1,31 @@
 package main
 
+import "time"
+
 // Visit contains visit database record.
 type Visit struct {
-	ID        *uint32 `json:"id" db:"id"`
-	Location  *uint32 `json:"location" db:"location"`
-	User      *uint32 `json:"user" db:"user"`
-	VisitedAt *int32  `json:"visited_at" db:"visited_at"`
-	Mark      *uint8  `json:"mark" db:"mark"`
+	ID        *uint32    `json:"id" db:"id"`
+	Location  *uint32    `json:"location" db:"location"`
+	User      *uint32    `json:"user" db:"user"`
+	VisitedAt *int32     `json:"visited_at" db:"visited_at"`
+	Mark      *uint8     `json:"mark" db:"mark"`
+	Version   *uint64    `json:"version,omitempty" db:"version"`
+	UpdatedAt *time.Time `json:"-" db:"updated_at"`
 }
 
 // Visits contains slice of visits.
 type Visits struct {
 	Rows []*Visit `json:"visits"`
+	Next *uint32  `json:"next,omitempty"`
+}
+
+// VisitListFilter contains visits filtering parameters from requests.
+type VisitListFilter struct {
+	Page
+	User     *uint32 `schema:"user"`
+	Location *uint32 `schema:"location"`
+	FromDate *int32  `schema:"fromDate"`
+	ToDate   *int32  `schema:"toDate"`
+	FromMark *uint8  `schema:"fromMark"`
+	ToMark   *uint8  `schema:"toMark"`
 }
@@ -0,0 +1,15 @@
+package main
+
+// Visitor contains short description about user who visited location.
+type Visitor struct {
+	User      uint32  `json:"user" db:"user"`
+	Gender    *string `json:"gender" db:"gender"`
+	Age       int32   `json:"age" db:"age"`
+	Mark      *uint8  `json:"mark" db:"mark"`
+	VisitedAt int32   `json:"visited_at" db:"visited_at"`
+}
+
+// Visitors contains slice of visitors.
+type Visitors struct {
+	Rows []*Visitor `json:"visits"`
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	webhookSignatureHeader = "X-Webhook-Signature"
+	webhookEventHeader     = "X-Webhook-Event"
+	webhookDeliveryHeader  = "X-Webhook-Delivery"
+
+	// webhookBatchSize is maximum number of deliveries claimed at once.
+	webhookBatchSize = 100
+	// webhookTimeout is time given to receiver to respond to delivery.
+	webhookTimeout = 10 * time.Second
+	// webhookLease is time claimed deliveries are hidden from other claims,
+	// it must be enough to attempt the whole batch.
+	webhookLease = webhookBatchSize * webhookTimeout
+	// webhookPollInterval is interval between checks for deliveries due to retry.
+	webhookPollInterval = 5 * time.Second
+	// webhookMinBackoff and webhookMaxBackoff bound exponentially growing
+	// delay between delivery attempts.
+	webhookMinBackoff = time.Second
+	webhookMaxBackoff = time.Hour
+	// webhookMaxAttempts is number of attempts after which delivery is dropped.
+	webhookMaxAttempts = 15
+)
+
+// Webhook contains subscription to changes of entities. Changes of all
+// entities are delivered when entities are not specified.
+type Webhook struct {
+	ID       *uint32        `json:"id" db:"id"`
+	URL      *string        `json:"url" db:"url"`
+	Secret   *string        `json:"secret,omitempty" db:"secret"`
+	Entities pq.StringArray `json:"entities" db:"entities"`
+}
+
+// Webhooks contains slice of webhooks.
+type Webhooks struct {
+	Rows []*Webhook `json:"webhooks"`
+}
+
+// Validate checks that webhook has http(s) URL of a public host and
+// subscribes to known entities.
+func (w *Webhook) Validate() error {
+	if w.URL == nil {
+		return errors.New("webhook url is required")
+	}
+	u, err := url.Parse(*w.URL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("invalid webhook url %q", *w.URL)
+	}
+	ips, err := net.LookupIP(u.Hostname())
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		if !webhookAddressAllowed(ip) {
+			return fmt.Errorf("webhook url %q refers to not allowed address %s", *w.URL, ip)
+		}
+	}
+
+	for _, entity := range w.Entities {
+		switch entity {
+		case usersTableName, locationsTableName, visitsTableName:
+		default:
+			return fmt.Errorf("unknown webhook entity %q", entity)
+		}
+	}
+	return nil
+}
+
+// webhookAddressAllowed reports whether webhooks may be delivered to the
+// address. Loopback, private and link-local addresses are not allowed,
+// so that webhooks cannot be used to reach internal services.
+func webhookAddressAllowed(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast()
+}
+
+// webhookDialControl refuses connections to addresses not allowed for
+// webhooks. Addresses are checked on connect, since host names of webhooks
+// may resolve to different addresses after webhooks are validated.
+func webhookDialControl(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !webhookAddressAllowed(ip) {
+		return fmt.Errorf("webhook address %s is not allowed", host)
+	}
+	return nil
+}
+
+// webhookDelivery contains change to be delivered to webhook.
+type webhookDelivery struct {
+	ChangeEvent
+	Delivery uint64 `db:"id"`
+	Webhook  uint32 `db:"webhook"`
+	URL      string `db:"url"`
+	Secret   string `db:"secret"`
+	Attempts int    `db:"attempts"`
+}
+
+// webhookSignature returns hex encoded HMAC-SHA256 of body keyed by secret.
+func webhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff returns delay before the next attempt of delivery.
+func webhookBackoff(attempts int) time.Duration {
+	delay := webhookMinBackoff
+	for i := 1; i < attempts && delay < webhookMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > webhookMaxBackoff {
+		delay = webhookMaxBackoff
+	}
+	return delay
+}
+
+// webhookQueue contains deliveries of changes to webhooks, it is
+// implemented by Database.
+type webhookQueue interface {
+	claimWebhookDeliveries(limit int, lease time.Duration) ([]*webhookDelivery, error)
+	completeWebhookDelivery(delivery *webhookDelivery) error
+	retryWebhookDelivery(delivery *webhookDelivery, delay time.Duration, cause error) error
+}
+
+// webhookDispatcher delivers queued changes to webhooks.
+type webhookDispatcher struct {
+	queue  webhookQueue
+	client *http.Client
+}
+
+func newWebhookDispatcher(queue webhookQueue) *webhookDispatcher {
+	dialer := &net.Dialer{Timeout: webhookTimeout, Control: webhookDialControl}
+	return &webhookDispatcher{
+		queue: queue,
+		client: &http.Client{
+			Timeout:   webhookTimeout,
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+		},
+	}
+}
+
+// run delivers queued changes when notified about new changes and
+// periodically to retry failed deliveries.
+func (w *webhookDispatcher) run(notifications <-chan struct{}) {
+	poll := time.NewTicker(webhookPollInterval)
+	defer poll.Stop()
+
+	for {
+		if w.dispatch() == webhookBatchSize {
+			continue
+		}
+
+		select {
+		case <-notifications:
+		case <-poll.C:
+		}
+	}
+}
+
+// dispatch delivers batch of due deliveries and returns its size.
+func (w *webhookDispatcher) dispatch() int {
+	deliveries, err := w.queue.claimWebhookDeliveries(webhookBatchSize, webhookLease)
+	if err != nil {
+		return 0
+	}
+
+	for _, delivery := range deliveries {
+		err := w.deliver(delivery)
+		switch {
+		case err == nil:
+			err = w.queue.completeWebhookDelivery(delivery)
+		case delivery.Attempts >= webhookMaxAttempts:
+			log.Printf("webhook %d: dropping change %d after %d attempts: %v",
+				delivery.Webhook, delivery.Seq, delivery.Attempts, err)
+			err = w.queue.completeWebhookDelivery(delivery)
+		default:
+			err = w.queue.retryWebhookDelivery(delivery, webhookBackoff(delivery.Attempts), err)
+		}
+		if err != nil {
+			log.Println(err)
+		}
+	}
+	return len(deliveries)
+}
+
+// deliver posts change to the webhook URL signing it with webhook secret.
+// Delivery succeeds when receiver responds with 2xx status.
+func (w *webhookDispatcher) deliver(delivery *webhookDelivery) error {
+	body, err := json.Marshal(delivery.ChangeEvent)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookEventHeader, delivery.Operation)
+	req.Header.Set(webhookDeliveryHeader, fmt.Sprint(delivery.Delivery))
+	if delivery.Secret != "" {
+		req.Header.Set(webhookSignatureHeader, webhookSignature(delivery.Secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
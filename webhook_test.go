@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryWebhookQueue keeps deliveries in memory, retried deliveries are due immediately.
+type memoryWebhookQueue struct {
+	mu         sync.Mutex
+	deliveries []*webhookDelivery
+	errors     []error
+}
+
+func (q *memoryWebhookQueue) claimWebhookDeliveries(limit int, lease time.Duration) ([]*webhookDelivery, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.deliveries) > limit {
+		return nil, nil
+	}
+	claimed := q.deliveries
+	q.deliveries = nil
+	for _, delivery := range claimed {
+		delivery.Attempts++
+	}
+	return claimed, nil
+}
+
+func (q *memoryWebhookQueue) completeWebhookDelivery(delivery *webhookDelivery) error {
+	return nil
+}
+
+func (q *memoryWebhookQueue) retryWebhookDelivery(delivery *webhookDelivery, delay time.Duration, cause error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.deliveries = append(q.deliveries, delivery)
+	q.errors = append(q.errors, cause)
+	return nil
+}
+
+// receivedChange contains change decoded by webhook receiver, JSONB does
+// not implement json.Unmarshaler, so changed fields are kept raw.
+type receivedChange struct {
+	ChangeEvent
+	Changed json.RawMessage `json:"changed"`
+}
+
+func TestWebhookDeliveryRetry(t *testing.T) {
+	const secret = "secret"
+
+	var mu sync.Mutex
+	var received []receivedChange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		if got, want := r.Header.Get(webhookSignatureHeader), webhookSignature(secret, body); got != want {
+			t.Errorf("signature is %q, want %q", got, want)
+		}
+		if got := r.Header.Get(webhookEventHeader); got != "create" {
+			t.Errorf("event is %q, want create", got)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		var change receivedChange
+		if err := json.Unmarshal(body, &change); err != nil {
+			t.Error(err)
+		}
+		received = append(received, change)
+		if len(received) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	queue := &memoryWebhookQueue{deliveries: []*webhookDelivery{{
+		ChangeEvent: ChangeEvent{Seq: 7, Entity: usersTableName, ID: 1, Operation: "create", Changed: JSONB(`{"id":1}`)},
+		Delivery:    1,
+		Webhook:     1,
+		URL:         server.URL,
+		Secret:      secret,
+	}}}
+	dispatcher := newWebhookDispatcher(queue)
+	dispatcher.client = server.Client()
+
+	if n := dispatcher.dispatch(); n != 1 {
+		t.Fatalf("first dispatch delivered %d changes, want 1", n)
+	}
+	if len(queue.deliveries) != 1 || len(queue.errors) != 1 {
+		t.Fatalf("failed delivery is not retried")
+	}
+	if n := dispatcher.dispatch(); n != 1 {
+		t.Fatalf("second dispatch delivered %d changes, want 1", n)
+	}
+	if len(queue.deliveries) != 0 {
+		t.Errorf("successful delivery is retried")
+	}
+
+	if len(received) != 2 {
+		t.Fatalf("receiver got %d requests, want 2", len(received))
+	}
+	if change := received[1]; change.Seq != 7 || change.Entity != usersTableName || change.ID != 1 || string(change.Changed) != `{"id":1}` {
+		t.Errorf("receiver got change %+v", change)
+	}
+}
+
+func TestWebhookLoopbackRefused(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("delivered to loopback address")
+	}))
+	defer server.Close()
+
+	url := server.URL
+	if err := (&Webhook{URL: &url}).Validate(); err == nil {
+		t.Error("webhook with loopback url is valid")
+	}
+
+	delivery := &webhookDelivery{URL: server.URL, ChangeEvent: ChangeEvent{Operation: "create"}}
+	if err := newWebhookDispatcher(new(memoryWebhookQueue)).deliver(delivery); err == nil {
+		t.Error("delivery to loopback address succeeded")
+	}
+}